@@ -0,0 +1,463 @@
+package bitcask
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+)
+
+const (
+	dataFileName  = "kv.data"
+	hintFileName  = "kv.hint"
+	mergeFileName = "kv.data.merge"
+)
+
+// Bitcask is a Key-Value Store in a single append-only log file,
+// with an in-memory key directory, like Bitcask.
+type Bitcask struct {
+	sync.RWMutex
+	Config *Config
+
+	file    *os.File
+	size    int64
+	keydir  map[string]entry
+	garbage int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// Config is the configuration for Bitcask.
+type Config struct {
+	// Dir is the directory to store the data file and hint file.
+	Dir string
+
+	// MergeInterval is the interval to check whether the data file should be compacted.
+	// Zero disables periodic merge, Merge can still be called manually.
+	MergeInterval time.Duration
+
+	// MergeRatio is the ratio of stale bytes in the data file which triggers a periodic merge.
+	// Default is 0.5.
+	MergeRatio float64
+}
+
+// entry is a key directory entry, pointing to the latest record of a key.
+type entry struct {
+	offset    int64
+	size      int64
+	expiresAt int64
+}
+
+// New returns a new Bitcask.
+func New(cfg ...*Config) (*Bitcask, error) {
+	cfgX := &Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		cfgX = cfg[0]
+	}
+
+	if cfgX.Dir == "" {
+		homeDir, _ := os.UserHomeDir()
+		cfgX.Dir = filepath.Join(homeDir, ".cache/go-zoox/kv/bitcask")
+	}
+	if cfgX.MergeRatio <= 0 {
+		cfgX.MergeRatio = 0.5
+	}
+
+	if err := os.MkdirAll(cfgX.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	m := &Bitcask{
+		Config: cfgX,
+		keydir: make(map[string]entry),
+		done:   make(chan struct{}),
+	}
+
+	if err := m.open(); err != nil {
+		return nil, err
+	}
+
+	if cfgX.MergeInterval > 0 {
+		m.wg.Add(1)
+		go m.runMerge()
+	}
+
+	return m, nil
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+func (m *Bitcask) pathOf(name string) string {
+	return filepath.Join(m.Config.Dir, name)
+}
+
+func (e entry) expired() bool {
+	return e.expiresAt > 0 && e.expiresAt < now()
+}
+
+// Set sets the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge miliseconds.
+func (m *Bitcask) Set(key string, value any, maxAge ...time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	expiresAt := int64(0)
+	if len(maxAge) > 0 {
+		expiresAt = now() + int64(maxAge[0]/time.Millisecond)
+	} else if e, ok := m.keydir[key]; ok && !e.expired() {
+		expiresAt = e.expiresAt
+	}
+
+	return m.append(key, raw, expiresAt)
+}
+
+// Replace sets the value for the given key, which never expires.
+func (m *Bitcask) Replace(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	return m.append(key, raw, 0)
+}
+
+// Get returns the value for the given key.
+func (m *Bitcask) Get(key string, value any) error {
+	m.RLock()
+	e, ok := m.keydir[key]
+	if !ok {
+		m.RUnlock()
+		return fmt.Errorf("key %s not found", key)
+	}
+
+	if e.expired() {
+		m.RUnlock()
+		m.Delete(key)
+		return fmt.Errorf("key %s expired", key)
+	}
+
+	r, err := readRecordAt(m.file, e.offset, m.size)
+	m.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(r.value, value)
+}
+
+// Delete deletes the value for the given key.
+func (m *Bitcask) Delete(key string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.keydir[key]; !ok {
+		return nil
+	}
+
+	return m.append(key, nil, 0)
+}
+
+// Has returns true if the given key exists in the kv.
+func (m *Bitcask) Has(key string) bool {
+	m.RLock()
+	e, ok := m.keydir[key]
+	m.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	if e.expired() {
+		m.Delete(key)
+		return false
+	}
+
+	return true
+}
+
+// Keys returns the keys of the kv.
+func (m *Bitcask) Keys() []string {
+	return m.keys(func(key string) bool { return true })
+}
+
+// KeysWithPrefix returns the keys starting with the given prefix.
+func (m *Bitcask) KeysWithPrefix(prefix string) []string {
+	return m.keys(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// KeysMatching returns the keys matching the glob pattern.
+func (m *Bitcask) KeysMatching(pattern string) []string {
+	return m.keys(func(key string) bool { return glob.Match(pattern, key) })
+}
+
+// keys returns the unexpired keys of the key directory accepted by match.
+func (m *Bitcask) keys(match func(key string) bool) []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	keys := []string{}
+	for k, e := range m.keydir {
+		if e.expired() || !match(k) {
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// DeletePrefix deletes the keys starting with the given prefix, writing a tombstone per key.
+func (m *Bitcask) DeletePrefix(prefix string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for k := range m.keydir {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		if err := m.append(k, nil, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Size returns the number of elements in the kv.
+func (m *Bitcask) Size() int {
+	return len(m.Keys())
+}
+
+// Clear removes all elements from the kv.
+func (m *Bitcask) Clear() error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.file.Truncate(0); err != nil {
+		return err
+	}
+	if err := os.Remove(m.pathOf(hintFileName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	m.size = 0
+	m.garbage = 0
+	m.keydir = make(map[string]entry)
+	return nil
+}
+
+// ForEach calls the given function for each key-value pair in the kv.
+func (m *Bitcask) ForEach(f func(string, interface{})) {
+	for _, key := range m.Keys() {
+		var value any
+		if err := m.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
+
+// Merge compacts the data file, dropping overwritten, deleted and expired records,
+// and writes a hint file for fast startup.
+func (m *Bitcask) Merge() error {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.merge()
+}
+
+// Close writes the hint file and closes the data file.
+func (m *Bitcask) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	m.wg.Wait()
+
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.writeHint(m.pathOf(hintFileName)); err != nil {
+		m.file.Close()
+		return err
+	}
+
+	return m.file.Close()
+}
+
+func (m *Bitcask) runMerge() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.Config.MergeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.Lock()
+			if m.size > 0 && float64(m.garbage)/float64(m.size) >= m.Config.MergeRatio {
+				m.merge()
+			}
+			m.Unlock()
+		}
+	}
+}
+
+// append writes a record to the end of the data file and updates the key directory.
+// A nil value writes a tombstone.
+func (m *Bitcask) append(key string, value []byte, expiresAt int64) error {
+	buf := encodeRecord(&record{key: key, value: value, expiresAt: expiresAt})
+	if _, err := m.file.WriteAt(buf, m.size); err != nil {
+		return err
+	}
+
+	offset := m.size
+	m.size += int64(len(buf))
+
+	if old, ok := m.keydir[key]; ok {
+		m.garbage += old.size
+	}
+
+	if value == nil {
+		delete(m.keydir, key)
+		m.garbage += int64(len(buf))
+		return nil
+	}
+
+	m.keydir[key] = entry{offset, int64(len(buf)), expiresAt}
+	return nil
+}
+
+// open loads the key directory from the hint file, if any,
+// then replays the data file records written after it.
+func (m *Bitcask) open() error {
+	file, err := os.OpenFile(m.pathOf(dataFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	m.file = file
+
+	offset := int64(0)
+	if hinted, ok := m.readHint(m.pathOf(hintFileName), stat.Size()); ok {
+		offset = hinted
+	}
+
+	for offset < stat.Size() {
+		r, err := readRecordAt(file, offset, stat.Size())
+		if err == io.ErrUnexpectedEOF {
+			// drop a torn tail, left by a crash in the middle of a write
+			if err := file.Truncate(offset); err != nil {
+				file.Close()
+				return err
+			}
+			break
+		}
+		if err != nil {
+			// a corrupted record may be followed by valid ones, which must not be dropped
+			file.Close()
+			return fmt.Errorf("%w at offset %d", err, offset)
+		}
+
+		size := r.size()
+		if old, ok := m.keydir[r.key]; ok {
+			m.garbage += old.size
+		}
+
+		if r.value == nil {
+			delete(m.keydir, r.key)
+			m.garbage += size
+		} else {
+			m.keydir[r.key] = entry{offset, size, r.expiresAt}
+		}
+
+		offset += size
+	}
+
+	m.size = offset
+	return nil
+}
+
+func (m *Bitcask) merge() error {
+	mergePath := m.pathOf(mergeFileName)
+	file, err := os.OpenFile(mergePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	keydir := make(map[string]entry, len(m.keydir))
+	size := int64(0)
+	for key, e := range m.keydir {
+		if e.expired() {
+			continue
+		}
+
+		r, err := readRecordAt(m.file, e.offset, m.size)
+		if err != nil {
+			file.Close()
+			os.Remove(mergePath)
+			return err
+		}
+
+		buf := encodeRecord(r)
+		if _, err := file.WriteAt(buf, size); err != nil {
+			file.Close()
+			os.Remove(mergePath)
+			return err
+		}
+
+		keydir[key] = entry{size, int64(len(buf)), e.expiresAt}
+		size += int64(len(buf))
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(mergePath)
+		return err
+	}
+
+	// the old hint file no longer matches the data file
+	if err := os.Remove(m.pathOf(hintFileName)); err != nil && !os.IsNotExist(err) {
+		file.Close()
+		return err
+	}
+
+	if err := os.Rename(mergePath, m.pathOf(dataFileName)); err != nil {
+		file.Close()
+		return err
+	}
+
+	m.file.Close()
+	m.file = file
+	m.size = size
+	m.garbage = 0
+	m.keydir = keydir
+
+	return m.writeHint(m.pathOf(hintFileName))
+}
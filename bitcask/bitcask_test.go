@@ -0,0 +1,159 @@
+package bitcask
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-zoox/kv/test"
+)
+
+func createClient(t *testing.T, dir string) *Bitcask {
+	client, err := New(&Config{
+		Dir: dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestKV(t *testing.T) {
+	client := createClient(t, t.TempDir())
+	defer client.Close()
+
+	test.RunTestCases(t, client)
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	client := createClient(t, dir)
+	client.Set("key1", "value1")
+	client.Set("key2", "value2")
+	client.Set("key1", "value1-2")
+	client.Delete("key2")
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// records written after the hint file must be replayed too
+	client = createClient(t, dir)
+	client.Set("key3", "value3")
+	client.file.Close()
+
+	client = createClient(t, dir)
+	defer client.Close()
+
+	if client.Size() != 2 {
+		t.Fatalf("Expected size 2, got %d", client.Size())
+	}
+
+	var value string
+	if err := client.Get("key1", &value); err != nil || value != "value1-2" {
+		t.Errorf("Expected value to be 'value1-2', got %s", value)
+	}
+	if err := client.Get("key3", &value); err != nil || value != "value3" {
+		t.Errorf("Expected value to be 'value3', got %s", value)
+	}
+	if client.Has("key2") {
+		t.Error("Expected key2 to be deleted")
+	}
+}
+
+func TestTornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	client := createClient(t, dir)
+	client.Set("key1", "value1")
+	client.Set("key2", "value2")
+	size := client.size
+	client.file.Close()
+
+	// simulate a crash in the middle of writing the second record
+	if err := os.Truncate(client.pathOf(dataFileName), size-3); err != nil {
+		t.Fatal(err)
+	}
+
+	client = createClient(t, dir)
+	defer client.Close()
+
+	if client.Size() != 1 || !client.Has("key1") {
+		t.Fatalf("Expected only key1 to survive, got %v", client.Keys())
+	}
+	if err := client.Set("key2", "value2"); err != nil {
+		t.Fatal(err)
+	}
+
+	var value string
+	if err := client.Get("key2", &value); err != nil || value != "value2" {
+		t.Errorf("Expected value to be 'value2', got %s", value)
+	}
+}
+
+func TestCorrupted(t *testing.T) {
+	dir := t.TempDir()
+
+	client := createClient(t, dir)
+	client.Set("key1", "value1")
+	client.Set("key2", "value2")
+	client.file.Close()
+
+	// flip a byte of the first record, followed by a valid one
+	file, err := os.OpenFile(client.pathOf(dataFileName), os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1)
+	file.ReadAt(buf, headerSize)
+	buf[0] ^= 0xff
+	file.WriteAt(buf, headerSize)
+	file.Close()
+
+	if _, err := New(&Config{Dir: dir}); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("Expected ErrCorrupted, got %v", err)
+	}
+
+	stat, err := os.Stat(client.pathOf(dataFileName))
+	if err != nil || stat.Size() != client.size {
+		t.Errorf("Expected the data file to be kept, got %v", err)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	client := createClient(t, t.TempDir())
+	client.Close()
+	client.Close()
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+
+	client := createClient(t, dir)
+	for i := 0; i < 100; i++ {
+		client.Set("key", i)
+	}
+	client.Set("other", "value")
+	client.Delete("other")
+
+	before := client.size
+	if err := client.Merge(); err != nil {
+		t.Fatal(err)
+	}
+	if client.size >= before {
+		t.Errorf("Expected data file to shrink, got %d >= %d", client.size, before)
+	}
+	client.file.Close()
+
+	client = createClient(t, dir)
+	defer client.Close()
+
+	var value int
+	if err := client.Get("key", &value); err != nil || value != 99 {
+		t.Errorf("Expected value to be 99, got %d", value)
+	}
+	if client.Size() != 1 {
+		t.Errorf("Expected size 1, got %d", client.Size())
+	}
+}
@@ -0,0 +1,175 @@
+package bitcask
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// headerSize is the size of a record header:
+//
+//	crc(4) | expiresAt(8) | keySize(4) | valueSize(4) | tombstone(1)
+const headerSize = 21
+
+// hintMagic marks a hint file.
+var hintMagic = []byte("KVBCHINT")
+
+// ErrCorrupted means a record or hint file failed its CRC check.
+var ErrCorrupted = errors.New("bitcask: corrupted record")
+
+// record is a single entry in the data file.
+// A record with a nil value is a tombstone.
+type record struct {
+	key       string
+	value     []byte
+	expiresAt int64
+}
+
+func (r *record) size() int64 {
+	return int64(headerSize + len(r.key) + len(r.value))
+}
+
+func encodeRecord(r *record) []byte {
+	buf := make([]byte, r.size())
+	binary.BigEndian.PutUint64(buf[4:12], uint64(r.expiresAt))
+	binary.BigEndian.PutUint32(buf[12:16], uint32(len(r.key)))
+	binary.BigEndian.PutUint32(buf[16:20], uint32(len(r.value)))
+	if r.value == nil {
+		buf[20] = 1
+	}
+	copy(buf[headerSize:], r.key)
+	copy(buf[headerSize+len(r.key):], r.value)
+
+	binary.BigEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[4:]))
+	return buf
+}
+
+// readRecordAt reads the record at offset, which must end before end.
+func readRecordAt(file *os.File, offset, end int64) (*record, error) {
+	if offset+headerSize > end {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := file.ReadAt(header, offset); err != nil {
+		return nil, err
+	}
+
+	keySize := binary.BigEndian.Uint32(header[12:16])
+	valueSize := binary.BigEndian.Uint32(header[16:20])
+	if offset+headerSize+int64(keySize)+int64(valueSize) > end {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	body := make([]byte, int(keySize)+int(valueSize))
+	if _, err := file.ReadAt(body, offset+headerSize); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	crc := crc32.ChecksumIEEE(header[4:])
+	crc = crc32.Update(crc, crc32.IEEETable, body)
+	if crc != binary.BigEndian.Uint32(header[0:4]) {
+		return nil, ErrCorrupted
+	}
+
+	r := &record{
+		key:       string(body[:keySize]),
+		expiresAt: int64(binary.BigEndian.Uint64(header[4:12])),
+	}
+	if header[20] == 0 {
+		r.value = body[keySize:]
+	}
+
+	return r, nil
+}
+
+// writeHint writes the key directory to a hint file, so that the next open
+// does not need to scan the whole data file.
+//
+//	magic(8) | dataSize(8) | crc(4) | entries...
+//	entry: keySize(4) | offset(8) | size(8) | expiresAt(8) | key
+func (m *Bitcask) writeHint(path string) error {
+	var body bytes.Buffer
+	buf := make([]byte, 28)
+	for key, e := range m.keydir {
+		binary.BigEndian.PutUint32(buf[0:4], uint32(len(key)))
+		binary.BigEndian.PutUint64(buf[4:12], uint64(e.offset))
+		binary.BigEndian.PutUint64(buf[12:20], uint64(e.size))
+		binary.BigEndian.PutUint64(buf[20:28], uint64(e.expiresAt))
+		body.Write(buf)
+		body.WriteString(key)
+	}
+
+	header := make([]byte, len(hintMagic)+12)
+	copy(header, hintMagic)
+	binary.BigEndian.PutUint64(header[8:16], uint64(m.size))
+	binary.BigEndian.PutUint32(header[16:20], crc32.ChecksumIEEE(body.Bytes()))
+
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(header, body.Bytes()...)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// readHint loads the key directory from a hint file.
+// It returns the data file offset covered by the hint, and false if the hint
+// is missing, corrupted or does not match the data file.
+func (m *Bitcask) readHint(path string, dataSize int64) (int64, bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil || len(raw) < len(hintMagic)+12 || !bytes.Equal(raw[:8], hintMagic) {
+		return 0, false
+	}
+
+	hinted := int64(binary.BigEndian.Uint64(raw[8:16]))
+	body := raw[20:]
+	if hinted > dataSize || crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(raw[16:20]) {
+		return 0, false
+	}
+
+	keydir := make(map[string]entry)
+	live := int64(0)
+	for len(body) > 0 {
+		if len(body) < 28 {
+			return 0, false
+		}
+
+		keySize := int(binary.BigEndian.Uint32(body[0:4]))
+		if len(body) < 28+keySize {
+			return 0, false
+		}
+
+		e := entry{
+			offset:    int64(binary.BigEndian.Uint64(body[4:12])),
+			size:      int64(binary.BigEndian.Uint64(body[12:20])),
+			expiresAt: int64(binary.BigEndian.Uint64(body[20:28])),
+		}
+		keydir[string(body[28:28+keySize])] = e
+		live += e.size
+		body = body[28+keySize:]
+	}
+
+	m.keydir = keydir
+	m.garbage = hinted - live
+	return hinted, true
+}
@@ -0,0 +1,301 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt is a Key-Value Store in a bbolt database file.
+type Bolt struct {
+	Core   *bolt.DB
+	Config *Config
+}
+
+// Config is the configuration for Bolt.
+type Config struct {
+	// Path is the path of the database file.
+	Path string
+
+	// Bucket is the bucket to store the entries, which works as the namespace of the kv.
+	// Default is kv.
+	Bucket string
+
+	// Timeout is the time to wait for the file lock, default is 1 second.
+	Timeout time.Duration
+}
+
+// New returns a new Bolt.
+func New(cfg *Config) (*Bolt, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("bolt path is required")
+	}
+	if cfg.Bucket == "" {
+		cfg.Bucket = "kv"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Second
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+		return nil, err
+	}
+
+	core, err := bolt.Open(cfg.Path, 0644, &bolt.Options{Timeout: cfg.Timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = core.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(cfg.Bucket))
+		return err
+	})
+	if err != nil {
+		core.Close()
+		return nil, err
+	}
+
+	return &Bolt{
+		Core:   core,
+		Config: cfg,
+	}, nil
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+// encodeValue stores expiresAt in the first 8 bytes, followed by the json value.
+func encodeValue(raw []byte, expiresAt int64) []byte {
+	buf := make([]byte, 8+len(raw))
+	binary.BigEndian.PutUint64(buf, uint64(expiresAt))
+	copy(buf[8:], raw)
+	return buf
+}
+
+func decodeValue(buf []byte) (raw []byte, expiresAt int64) {
+	if len(buf) < 8 {
+		return nil, 0
+	}
+
+	return buf[8:], int64(binary.BigEndian.Uint64(buf[:8]))
+}
+
+func expired(expiresAt int64) bool {
+	return expiresAt > 0 && expiresAt < now()
+}
+
+func (m *Bolt) bucket(tx *bolt.Tx) *bolt.Bucket {
+	return tx.Bucket([]byte(m.Config.Bucket))
+}
+
+// Set sets the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge miliseconds.
+func (m *Bolt) Set(key string, value any, maxAge ...time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return m.Core.Update(func(tx *bolt.Tx) error {
+		b := m.bucket(tx)
+
+		expiresAt := int64(0)
+		if len(maxAge) > 0 {
+			expiresAt = now() + int64(maxAge[0]/time.Millisecond)
+		} else if old := b.Get([]byte(key)); old != nil {
+			// use origin expiresAt
+			if _, oldExpiresAt := decodeValue(old); !expired(oldExpiresAt) {
+				expiresAt = oldExpiresAt
+			}
+		}
+
+		return b.Put([]byte(key), encodeValue(raw, expiresAt))
+	})
+}
+
+// Replace sets the value for the given key, which never expires.
+func (m *Bolt) Replace(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return m.Core.Update(func(tx *bolt.Tx) error {
+		return m.bucket(tx).Put([]byte(key), encodeValue(raw, 0))
+	})
+}
+
+// Get returns the value for the given key.
+func (m *Bolt) Get(key string, value any) error {
+	var raw []byte
+	err := m.Core.View(func(tx *bolt.Tx) error {
+		buf := m.bucket(tx).Get([]byte(key))
+		if buf == nil {
+			return fmt.Errorf("key %s not found", key)
+		}
+
+		data, expiresAt := decodeValue(buf)
+		if expired(expiresAt) {
+			return fmt.Errorf("key %s expired", key)
+		}
+
+		// buf is only valid during the transaction
+		raw = append([]byte{}, data...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, value)
+}
+
+// Delete deletes the value for the given key.
+func (m *Bolt) Delete(key string) error {
+	return m.Core.Update(func(tx *bolt.Tx) error {
+		return m.bucket(tx).Delete([]byte(key))
+	})
+}
+
+// Has returns true if the given key exists in the kv.
+func (m *Bolt) Has(key string) bool {
+	has := false
+	m.Core.View(func(tx *bolt.Tx) error {
+		buf := m.bucket(tx).Get([]byte(key))
+		if buf == nil {
+			return nil
+		}
+
+		_, expiresAt := decodeValue(buf)
+		has = !expired(expiresAt)
+		return nil
+	})
+
+	return has
+}
+
+// Keys returns the keys of the kv, in byte-wise order.
+func (m *Bolt) Keys() []string {
+	return m.KeysWithPrefix("")
+}
+
+// KeysWithPrefix returns the keys starting with the given prefix, in byte-wise order.
+func (m *Bolt) KeysWithPrefix(prefix string) []string {
+	keys := []string{}
+	m.Core.View(func(tx *bolt.Tx) error {
+		c := m.bucket(tx).Cursor()
+		p := []byte(prefix)
+		for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+			if _, expiresAt := decodeValue(v); expired(expiresAt) {
+				continue
+			}
+
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+
+	return keys
+}
+
+// KeysMatching returns the keys matching the glob pattern, in byte-wise order,
+// seeking to the literal prefix of the pattern.
+func (m *Bolt) KeysMatching(pattern string) []string {
+	keys := []string{}
+	for _, key := range m.KeysWithPrefix(glob.Prefix(pattern)) {
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// DeletePrefix deletes the keys starting with the given prefix.
+func (m *Bolt) DeletePrefix(prefix string) error {
+	return m.Core.Update(func(tx *bolt.Tx) error {
+		b := m.bucket(tx)
+
+		// deleting with the cursor while iterating skips entries, so collect first
+		var keys [][]byte
+		c := b.Cursor()
+		p := []byte(prefix)
+		for k, _ := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, _ = c.Next() {
+			keys = append(keys, append([]byte{}, k...))
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Size returns the number of elements in the kv.
+func (m *Bolt) Size() int {
+	return len(m.Keys())
+}
+
+// Clear removes all elements from the kv.
+func (m *Bolt) Clear() error {
+	return m.Core.Update(func(tx *bolt.Tx) error {
+		name := []byte(m.Config.Bucket)
+		if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+
+		_, err := tx.CreateBucket(name)
+		return err
+	})
+}
+
+// ForEach calls the given function for each key-value pair in the kv.
+func (m *Bolt) ForEach(f func(string, interface{})) {
+	for _, key := range m.Keys() {
+		var value any
+		if err := m.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
+
+// Cleanup deletes the expired entries.
+func (m *Bolt) Cleanup() error {
+	return m.Core.Update(func(tx *bolt.Tx) error {
+		b := m.bucket(tx)
+
+		// deleting with the cursor while iterating skips entries, so collect first
+		var keys [][]byte
+		b.ForEach(func(k, v []byte) error {
+			if _, expiresAt := decodeValue(v); expired(expiresAt) {
+				keys = append(keys, append([]byte{}, k...))
+			}
+			return nil
+		})
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close closes the database.
+func (m *Bolt) Close() error {
+	return m.Core.Close()
+}
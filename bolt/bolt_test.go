@@ -0,0 +1,67 @@
+package bolt
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-zoox/kv/test"
+)
+
+func createClient(t *testing.T, path string, bucket string) *Bolt {
+	client, err := New(&Config{
+		Path:   path,
+		Bucket: bucket,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestKV(t *testing.T) {
+	client := createClient(t, filepath.Join(t.TempDir(), "test.db"), "go-zoox-test")
+	defer client.Close()
+
+	test.RunTestCases(t, client)
+}
+
+func TestKeysWithPrefix(t *testing.T) {
+	client := createClient(t, filepath.Join(t.TempDir(), "test.db"), "")
+	defer client.Close()
+
+	for _, key := range []string{"user:2", "session:1", "user:1", "user:10"} {
+		if err := client.Set(key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := client.KeysWithPrefix("user:")
+	if strings.Join(keys, ",") != "user:1,user:10,user:2" {
+		t.Errorf("Expected ordered keys user:1,user:10,user:2, got %v", keys)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	client := createClient(t, path, "a")
+	client.Set("key", "a")
+	client.Close()
+
+	client = createClient(t, path, "b")
+	client.Set("key", "b")
+	if err := client.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	client = createClient(t, path, "a")
+	defer client.Close()
+
+	var value string
+	if err := client.Get("key", &value); err != nil || value != "a" {
+		t.Errorf("Expected value to be 'a', got %s", value)
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-zoox/kv"
+	"github.com/go-zoox/kv/typing"
+)
+
+// errFalse exits with 1 silently, for has on a missing key.
+var errFalse = errors.New("false")
+
+// context is the state of a command run.
+type context struct {
+	usage  string
+	flags  *flag.FlagSet
+	engine engineFlags
+	output string
+
+	ttl    time.Duration
+	prefix string
+	match  string
+	yes    bool
+	file   string
+	gzip   bool
+
+	migrate kv.MigrateOptions
+	to      string
+
+	stdin  io.Reader
+	stdout io.Writer
+
+	kv      typing.KV
+	printer *printer
+}
+
+func newContext(name string, stdin io.Reader, stdout, stderr io.Writer) *context {
+	ctx := &context{
+		flags:  flag.NewFlagSet("kv "+name, flag.ContinueOnError),
+		stdin:  stdin,
+		stdout: stdout,
+	}
+
+	ctx.flags.SetOutput(stderr)
+	ctx.engine.register(ctx.flags)
+	ctx.flags.StringVar(&ctx.output, "output", "table", "output format, json or table")
+	ctx.flags.StringVar(&ctx.output, "o", "table", "shorthand for --output")
+	return ctx
+}
+
+func ttlFlag(ctx *context) {
+	ctx.flags.DurationVar(&ctx.ttl, "ttl", 0, "time to live, such as 30s or 1h, default is to keep the current one")
+}
+
+func prefixFlag(ctx *context) {
+	ctx.flags.StringVar(&ctx.prefix, "prefix", "", "only the keys with the prefix")
+}
+
+func keysFlags(ctx *context) {
+	prefixFlag(ctx)
+	ctx.flags.StringVar(&ctx.match, "match", "", "only the keys matching the glob pattern, such as user:*:name")
+}
+
+func yesFlag(ctx *context) {
+	ctx.flags.BoolVar(&ctx.yes, "yes", false, "confirm to remove all keys")
+}
+
+func fileFlag(ctx *context) {
+	ctx.flags.StringVar(&ctx.file, "file", "", "file of the dump, default is stdin or stdout")
+}
+
+func dumpFlags(ctx *context) {
+	prefixFlag(ctx)
+	fileFlag(ctx)
+	ctx.flags.BoolVar(&ctx.gzip, "gzip", false, "compress the dump with gzip")
+}
+
+func migrateFlags(ctx *context) {
+	prefixFlag(ctx)
+	ctx.flags.StringVar(&ctx.to, "to", "", "DSN of the destination engine, required")
+	ctx.flags.IntVar(&ctx.migrate.Concurrency, "concurrency", 4, "number of keys copied at the same time")
+	ctx.flags.BoolVar(&ctx.migrate.DryRun, "dry-run", false, "count the keys to copy, without writing")
+	ctx.flags.StringVar(&ctx.migrate.Checkpoint, "checkpoint", "", "file of the copied keys, to resume an interrupted migration")
+}
+
+// parse parses the flags, which are allowed after the positional args.
+// Up to the n-th positional arg, an arg starting with "-" which is not a flag is a value, such as in kv set key -1,
+// and all the args after "--" are positional.
+func (ctx *context) parse(args []string, n int) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		if len(positional) < n && ctx.isValue(args[0]) {
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
+		}
+
+		if err := ctx.flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := ctx.flags.Args()
+		if i := len(args) - len(rest); i > 0 && args[i-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			break
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	return positional, nil
+}
+
+// isValue reports whether arg starts with "-" but is not a flag of the command.
+func (ctx *context) isValue(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+		return false
+	}
+
+	name := strings.TrimPrefix(arg[1:], "-")
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		name = name[:i]
+	}
+
+	return name != "h" && name != "help" && ctx.flags.Lookup(name) == nil
+}
+
+func (ctx *context) open() (err error) {
+	if ctx.printer, err = newPrinter(ctx.stdout, ctx.output); err != nil {
+		return err
+	}
+
+	ctx.kv, err = ctx.engine.open()
+	return err
+}
+
+func expectArgs(args []string, n int, usage string) error {
+	if len(args) != n {
+		return fmt.Errorf("usage: kv %s", usage)
+	}
+
+	return nil
+}
+
+// keys returns the sorted keys with the prefix, and matching the pattern if any.
+func (ctx *context) keys() []string {
+	var keys []string
+	if ctx.match != "" {
+		keys = []string{}
+		for _, key := range kv.KeysMatching(ctx.kv, ctx.match) {
+			if strings.HasPrefix(key, ctx.prefix) {
+				keys = append(keys, key)
+			}
+		}
+	} else {
+		keys = kv.KeysWithPrefix(ctx.kv, ctx.prefix)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// get returns the value of the key, or an error if it does not exist.
+func (ctx *context) get(key string) (any, error) {
+	if !ctx.kv.Has(key) {
+		return nil, fmt.Errorf("key %s not found", key)
+	}
+
+	var value any
+	if err := ctx.kv.Get(key, &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func runGet(ctx *context, args []string) error {
+	if err := expectArgs(args, 1, ctx.usage); err != nil {
+		return err
+	}
+
+	value, err := ctx.get(args[0])
+	if err != nil {
+		return err
+	}
+
+	return ctx.printer.value(value)
+}
+
+// runSet sets the value, which is parsed as JSON if valid, or stored as a string.
+func runSet(ctx *context, args []string) error {
+	if err := expectArgs(args, 2, ctx.usage); err != nil {
+		return err
+	}
+
+	var value any = args[1]
+	if json.Valid([]byte(args[1])) {
+		if err := json.Unmarshal([]byte(args[1]), &value); err != nil {
+			return err
+		}
+	}
+
+	if ctx.ttl > 0 {
+		return ctx.kv.Set(args[0], &value, ctx.ttl)
+	}
+
+	return ctx.kv.Set(args[0], &value)
+}
+
+func runDel(ctx *context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: kv %s", ctx.usage)
+	}
+
+	for _, key := range args {
+		if err := ctx.kv.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runHas(ctx *context, args []string) error {
+	if err := expectArgs(args, 1, ctx.usage); err != nil {
+		return err
+	}
+
+	has := ctx.kv.Has(args[0])
+	if err := ctx.printer.value(has); err != nil {
+		return err
+	}
+
+	if !has {
+		return errFalse
+	}
+
+	return nil
+}
+
+// runKeys lists the keys, with the time to live if the engine supports it.
+func runKeys(ctx *context, args []string) error {
+	if err := expectArgs(args, 0, ctx.usage); err != nil {
+		return err
+	}
+
+	keys := ctx.keys()
+	t, ok := ctx.kv.(typing.TTL)
+	if !ok {
+		if ctx.printer.json {
+			return ctx.printer.value(keys)
+		}
+
+		for _, key := range keys {
+			if err := ctx.printer.value(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	rows := [][]any{}
+	for _, key := range keys {
+		d, err := t.TTL(key)
+		if err != nil {
+			// expired since listed
+			continue
+		}
+
+		rows = append(rows, []any{key, ttl(d)})
+	}
+
+	return ctx.printer.table([]string{"KEY", "TTL"}, rows)
+}
+
+func runSize(ctx *context, args []string) error {
+	if err := expectArgs(args, 0, ctx.usage); err != nil {
+		return err
+	}
+
+	return ctx.printer.value(ctx.kv.Size())
+}
+
+func runClear(ctx *context, args []string) error {
+	if err := expectArgs(args, 0, ctx.usage); err != nil {
+		return err
+	}
+
+	if !ctx.yes {
+		return errors.New("clear removes all keys, confirm with --yes")
+	}
+
+	return ctx.kv.Clear()
+}
+
+func runTTL(ctx *context, args []string) error {
+	if err := expectArgs(args, 1, ctx.usage); err != nil {
+		return err
+	}
+
+	t, ok := ctx.kv.(typing.TTL)
+	if !ok {
+		return errors.New("the engine does not support ttl")
+	}
+
+	d, err := t.TTL(args[0])
+	if err != nil {
+		return err
+	}
+
+	return ctx.printer.value(ttl(d))
+}
+
+// runDump writes the keys in the dump format of kv.Dump.
+func runDump(ctx *context, args []string) error {
+	if err := expectArgs(args, 0, ctx.usage); err != nil {
+		return err
+	}
+
+	w := ctx.stdout
+	if ctx.file != "" {
+		f, err := os.Create(ctx.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	_, err := kv.Dump(ctx.kv, w, &kv.DumpOptions{Prefix: ctx.prefix, Gzip: ctx.gzip})
+	return err
+}
+
+// runLoad reads a dump of kv.Dump, gzipped or not.
+func runLoad(ctx *context, args []string) error {
+	if err := expectArgs(args, 0, ctx.usage); err != nil {
+		return err
+	}
+
+	r := ctx.stdin
+	if ctx.file != "" {
+		f, err := os.Open(ctx.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	_, err := kv.Restore(ctx.kv, r)
+	return err
+}
+
+// runMigrate copies the keys of the engine to the engine of --to.
+func runMigrate(ctx *context, args []string) error {
+	if err := expectArgs(args, 0, ctx.usage); err != nil {
+		return err
+	}
+	if ctx.to == "" {
+		return fmt.Errorf("--to is required, usage: kv %s", ctx.usage)
+	}
+
+	dst, err := kv.Open(ctx.to)
+	if err != nil {
+		return err
+	}
+
+	opts := ctx.migrate
+	opts.Prefix = ctx.prefix
+	result, err := kv.Migrate(ctx.kv, dst, &opts)
+	if result != nil {
+		if errX := ctx.printer.table(
+			[]string{"COPIED", "RESUMED", "EXPIRED", "FAILED"},
+			[][]any{{result.Copied, result.Resumed, result.Expired, result.Failed}},
+		); errX != nil {
+			return errX
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/go-zoox/kv"
+	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/redis"
+	"github.com/go-zoox/kv/typing"
+)
+
+// engineFlags are the flags to choose the engine, shared by all commands.
+type engineFlags struct {
+	DSN         string
+	Engine      string
+	Dir         string
+	RedisURI    string
+	RedisPrefix string
+}
+
+func (e *engineFlags) register(f *flag.FlagSet) {
+	f.StringVar(&e.DSN, "dsn", "", "engine DSN, such as memory://, file:///var/cache/app or redis://:pass@host:6379/0?prefix=app:")
+	f.StringVar(&e.Engine, "engine", "fs", "engine, one of memory, fs or redis, ignored if --dsn is set")
+	f.StringVar(&e.Dir, "dir", "", "directory of the fs engine, default is ~/.cache/go-zoox/kv/fs")
+	f.StringVar(&e.RedisURI, "redis-uri", "", "URI of the redis engine, such as redis://:pass@host:6379/0")
+	f.StringVar(&e.RedisPrefix, "redis-prefix", "", "key prefix of the redis engine")
+}
+
+// open creates the engine chosen by the flags.
+func (e *engineFlags) open() (typing.KV, error) {
+	if e.DSN != "" {
+		return kv.Open(e.DSN)
+	}
+
+	switch e.Engine {
+	case "memory":
+		return kv.NewMemory(), nil
+	case "fs", "filesystem":
+		return kv.NewFileSystem(&fs.FileSystemOptions{Dir: e.Dir})
+	case "redis":
+		return kv.NewRedis(&redis.Config{URI: e.RedisURI, Prefix: e.RedisPrefix})
+	default:
+		return nil, fmt.Errorf("unknown engine: %s", e.Engine)
+	}
+}
@@ -0,0 +1,120 @@
+// Command kv is a command-line tool to inspect and edit any KV engine.
+//
+// Usage:
+//
+//	kv <command> [flags] [args]
+//
+// The engine is chosen by --dsn, or by --engine with --dir, --redis-uri and --redis-prefix:
+//
+//	kv keys --dsn "redis://:pass@localhost:6379/0?prefix=app:" --prefix session:
+//	kv set --engine fs --dir /var/cache/app key '{"a":1}' --ttl 10m
+//	kv set --dir /var/cache/app -- key --value
+//	kv dump --dir /var/cache/app > backup.jsonl
+//	kv migrate --dir /var/cache/app --to "redis://localhost:6379/0?prefix=app:" --checkpoint migrate.txt
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// command is a subcommand of kv.
+type command struct {
+	usage string
+	// args is the number of the required positional args.
+	args int
+	// flags registers the flags of the command, if any.
+	flags func(ctx *context)
+	run   func(ctx *context, args []string) error
+}
+
+var commands = map[string]*command{
+	"get":   {"get <key>", 1, nil, runGet},
+	"set":   {"set <key> <value> [--ttl duration]", 2, ttlFlag, runSet},
+	"del":   {"del <key>...", 1, nil, runDel},
+	"has":   {"has <key>, exits with 1 if the key does not exist", 1, nil, runHas},
+	"keys":  {"keys [--prefix prefix] [--match pattern]", 0, keysFlags, runKeys},
+	"size":  {"size", 0, nil, runSize},
+	"clear": {"clear --yes", 0, yesFlag, runClear},
+	"ttl":   {"ttl <key>, -1 if the key never expires", 1, nil, runTTL},
+	"dump":  {"dump [--prefix prefix] [--file path] [--gzip], as JSON lines", 0, dumpFlags, runDump},
+	"load":  {"load [--file path], from a dump, gzipped or not", 0, fileFlag, runLoad},
+	"migrate": {
+		"migrate --to dsn [--prefix prefix] [--concurrency n] [--dry-run] [--checkpoint path]",
+		0, migrateFlags, runMigrate,
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command of args, and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) (code int) {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "kv: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	ctx := newContext(args[0], stdin, stdout, stderr)
+	ctx.usage = cmd.usage
+	if cmd.flags != nil {
+		cmd.flags(ctx)
+	}
+	positional, err := ctx.parse(args[1:], cmd.args)
+	if err != nil {
+		// the flag package has reported the error
+		return 2
+	}
+
+	// some engines panic on connection errors
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(stderr, "kv: %v\n", r)
+			code = 1
+		}
+	}()
+
+	if err := ctx.open(); err != nil {
+		fmt.Fprintf(stderr, "kv: %s\n", err)
+		return 1
+	}
+
+	if err := cmd.run(ctx, positional); err != nil {
+		if err == errFalse {
+			return 1
+		}
+
+		fmt.Fprintf(stderr, "kv: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: kv <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run kv <command> --help for the flags.")
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runKV runs the command against the fs engine in dir, and returns the exit code and stdout.
+func runKV(t *testing.T, dir string, stdin string, args ...string) (int, string) {
+	var stdout, stderr bytes.Buffer
+	args = append(args, "--dir", dir)
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	if code != 0 && stderr.Len() > 0 {
+		t.Logf("kv %s: %s", strings.Join(args, " "), stderr.String())
+	}
+
+	return code, stdout.String()
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+
+	if code, _ := runKV(t, dir, "", "set", "user:1", `{"name":"zero"}`, "--ttl", "1h"); code != 0 {
+		t.Fatalf("Expected set to succeed, got %d", code)
+	}
+	runKV(t, dir, "", "set", "user:2", "plain text")
+	runKV(t, dir, "", "set", "config", "42")
+
+	if _, out := runKV(t, dir, "", "get", "user:1"); out != `{"name":"zero"}`+"\n" {
+		t.Errorf("Expected JSON value, got %q", out)
+	}
+	if _, out := runKV(t, dir, "", "get", "user:2", "-o", "json"); out != `"plain text"`+"\n" {
+		t.Errorf("Expected JSON string, got %q", out)
+	}
+	if code, _ := runKV(t, dir, "", "get", "missing"); code != 1 {
+		t.Errorf("Expected get on missing key to fail, got %d", code)
+	}
+
+	if code, out := runKV(t, dir, "", "has", "user:1"); code != 0 || out != "true\n" {
+		t.Errorf("Expected has true, got %d %q", code, out)
+	}
+	if code, out := runKV(t, dir, "", "has", "missing"); code != 1 || out != "false\n" {
+		t.Errorf("Expected has false, got %d %q", code, out)
+	}
+
+	if _, out := runKV(t, dir, "", "keys", "--prefix", "user:", "-o", "json"); !strings.HasPrefix(out, `[{"key":"user:1","ttl":`) ||
+		!strings.HasSuffix(out, `{"key":"user:2","ttl":-1}]`+"\n") {
+		t.Errorf("Expected user keys with ttl, got %q", out)
+	}
+	if _, out := runKV(t, dir, "", "keys", "--prefix", "user:"); !strings.HasPrefix(out, "KEY     TTL\nuser:1  59m59.") ||
+		!strings.HasSuffix(out, "user:2  -1\n") {
+		t.Errorf("Expected user keys table, got %q", out)
+	}
+	if _, out := runKV(t, dir, "", "keys", "--match", "*:?", "-o", "json"); !strings.HasPrefix(out, `[{"key":"user:1","ttl":`) ||
+		!strings.HasSuffix(out, `{"key":"user:2","ttl":-1}]`+"\n") {
+		t.Errorf("Expected keys matching *:?, got %q", out)
+	}
+	if _, out := runKV(t, dir, "", "size"); out != "3\n" {
+		t.Errorf("Expected size 3, got %q", out)
+	}
+	if _, out := runKV(t, dir, "", "ttl", "config"); out != "-1\n" {
+		t.Errorf("Expected ttl -1, got %q", out)
+	}
+
+	if code, _ := runKV(t, dir, "", "del", "config", "user:2"); code != 0 {
+		t.Errorf("Expected del to succeed, got %d", code)
+	}
+	if code, _ := runKV(t, dir, "", "clear"); code != 1 {
+		t.Errorf("Expected clear without --yes to fail, got %d", code)
+	}
+	runKV(t, dir, "", "clear", "--yes")
+	if _, out := runKV(t, dir, "", "size"); out != "0\n" {
+		t.Errorf("Expected size 0, got %q", out)
+	}
+}
+
+func TestDashValues(t *testing.T) {
+	dir := t.TempDir()
+
+	if code, _ := runKV(t, dir, "", "set", "balance", "-1", "--ttl", "1h"); code != 0 {
+		t.Fatalf("Expected set of -1 to succeed, got %d", code)
+	}
+	if _, out := runKV(t, dir, "", "get", "balance"); out != "-1\n" {
+		t.Errorf("Expected -1, got %q", out)
+	}
+	if _, out := runKV(t, dir, "", "ttl", "balance"); out == "-1\n" {
+		t.Errorf("Expected the ttl flag after -1 to be parsed, got %q", out)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"set", "--dir", dir, "--", "-key", "--ttl"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected set after -- to succeed, got %d: %s", code, stderr.String())
+	}
+	if _, out := runKV(t, dir, "", "get", "-key"); out != "--ttl\n" {
+		t.Errorf("Expected --ttl, got %q", out)
+	}
+}
+
+func TestDumpLoad(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+
+	runKV(t, src, "", "set", "a", `[1,2]`, "--ttl", "1h")
+	runKV(t, src, "", "set", "b", "text")
+
+	_, dump := runKV(t, src, "", "dump")
+	lines := strings.Split(strings.TrimSpace(dump), "\n")
+	if len(lines) != 3 || lines[0] != `{"version":1}` || !strings.HasPrefix(lines[1], `{"key":"a","value":[1,2],"expiresAt":`) ||
+		lines[2] != `{"key":"b","value":"text","codec":"json"}` {
+		t.Fatalf("Unexpected dump %q", dump)
+	}
+
+	if code, _ := runKV(t, dst, dump, "load"); code != 0 {
+		t.Fatalf("Expected load to succeed, got %d", code)
+	}
+	if _, out := runKV(t, dst, "", "get", "a"); out != "[1,2]\n" {
+		t.Errorf("Expected loaded value, got %q", out)
+	}
+	if _, out := runKV(t, dst, "", "ttl", "a", "-o", "json"); out == "-1\n" {
+		t.Errorf("Expected loaded ttl, got %q", out)
+	}
+
+	file := filepath.Join(t.TempDir(), "dump.jsonl")
+	runKV(t, src, "", "dump", "--prefix", "b", "--file", file, "--gzip")
+	runKV(t, dst, "", "clear", "--yes")
+	runKV(t, dst, "", "load", "--file", file)
+	if _, out := runKV(t, dst, "", "keys"); out != "KEY  TTL\nb    -1\n" {
+		t.Errorf("Expected only b loaded, got %q", out)
+	}
+
+	if code, _ := runKV(t, dst, "not json\n", "load"); code != 1 {
+		t.Errorf("Expected load of invalid line to fail, got %d", code)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"set", "key", "value", "--dsn", "file://" + dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected set to succeed, got %d: %s", code, stderr.String())
+	}
+	if _, out := runKV(t, dir, "", "get", "key"); out != "value\n" {
+		t.Errorf("Expected value, got %q", out)
+	}
+
+	if code := run([]string{"size", "--dsn", "memory://?max=many"}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected invalid dsn to fail, got %d", code)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+
+	runKV(t, src, "", "set", "user:1", `{"name":"zero"}`, "--ttl", "1h")
+	runKV(t, src, "", "set", "user:2", "text")
+	runKV(t, src, "", "set", "config", "42")
+
+	if code, _ := runKV(t, src, "", "migrate"); code != 1 {
+		t.Errorf("Expected migrate without --to to fail, got %d", code)
+	}
+
+	_, out := runKV(t, src, "", "migrate", "--to", "file://"+dst, "--prefix", "user:", "--dry-run", "-o", "json")
+	if out != `[{"copied":2,"expired":0,"failed":0,"resumed":0}]`+"\n" {
+		t.Errorf("Expected dry-run result, got %q", out)
+	}
+	if _, out := runKV(t, dst, "", "size"); out != "0\n" {
+		t.Errorf("Expected no writes in dry-run, got %q", out)
+	}
+
+	_, out = runKV(t, src, "", "migrate", "--to", "file://"+dst, "--checkpoint", filepath.Join(t.TempDir(), "checkpoint"))
+	if out != "COPIED  RESUMED  EXPIRED  FAILED\n3       0        0        0\n" {
+		t.Errorf("Expected migrate result, got %q", out)
+	}
+	if _, out := runKV(t, dst, "", "get", "user:1"); out != `{"name":"zero"}`+"\n" {
+		t.Errorf("Expected migrated value, got %q", out)
+	}
+	if _, out := runKV(t, dst, "", "ttl", "user:1"); out == "-1\n" {
+		t.Errorf("Expected migrated ttl, got %q", out)
+	}
+}
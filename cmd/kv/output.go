@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// printer prints the results of the commands, as JSON or as a table.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, output string) (*printer, error) {
+	switch output {
+	case "table", "":
+		return &printer{w: w}, nil
+	case "json":
+		return &printer{w: w, json: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown output: %s, expected json or table", output)
+	}
+}
+
+// value prints a single value, strings are printed as is in a table.
+func (p *printer) value(v any) error {
+	if p.json {
+		return p.encode(v)
+	}
+
+	_, err := fmt.Fprintln(p.w, format(v))
+	return err
+}
+
+// table prints the rows with a header, or the rows as objects of the header in JSON.
+func (p *printer) table(header []string, rows [][]any) error {
+	if p.json {
+		objects := make([]map[string]any, len(rows))
+		for i, row := range rows {
+			objects[i] = make(map[string]any, len(header))
+			for j, column := range header {
+				objects[i][strings.ToLower(column)] = row[j]
+			}
+		}
+
+		return p.encode(objects)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		columns := make([]string, len(row))
+		for i, column := range row {
+			columns[i] = format(column)
+		}
+		fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func (p *printer) encode(v any) error {
+	return json.NewEncoder(p.w).Encode(v)
+}
+
+// format formats a value for a table, strings as is and others as JSON.
+func format(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case nil:
+		return "-"
+	case fmt.Stringer:
+		return x.String()
+	default:
+		raw, err := json.Marshal(x)
+		if err != nil {
+			return fmt.Sprint(x)
+		}
+		return string(raw)
+	}
+}
+
+// ttl is a time to live, in milliseconds in JSON, and -1 if the key never expires.
+type ttl time.Duration
+
+func (t ttl) MarshalJSON() ([]byte, error) {
+	if t < 0 {
+		return []byte("-1"), nil
+	}
+
+	return json.Marshal(time.Duration(t).Milliseconds())
+}
+
+func (t ttl) String() string {
+	if t < 0 {
+		return "-1"
+	}
+
+	return time.Duration(t).Round(time.Millisecond).String()
+}
@@ -0,0 +1,203 @@
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-zoox/kv/typing"
+)
+
+// DumpVersion is the version of the dump format written by Dump.
+const DumpVersion = 1
+
+// CodecJSON is the codec of the values encoded as JSON, which is the encoding of all engines.
+const CodecJSON = "json"
+
+// DumpHeader is the first line of a dump.
+type DumpHeader struct {
+	Version int `json:"version"`
+}
+
+// DumpEntry is a line of a dump, after the header.
+type DumpEntry struct {
+	Key string `json:"key"`
+	// Value is the value, encoded by Codec.
+	Value json.RawMessage `json:"value"`
+	// ExpiresAt is the expiry of the key, omitted if it never expires.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Codec     string     `json:"codec"`
+}
+
+// DumpOptions are the options of Dump.
+type DumpOptions struct {
+	// Prefix only dumps the keys with the prefix.
+	Prefix string
+
+	// Gzip compresses the dump.
+	Gzip bool
+}
+
+// Dump writes the keys of store to w as JSON lines, a DumpHeader then a DumpEntry per key,
+// and returns the number of dumped keys.
+// The expiry is kept if store implements typing.TTL.
+func Dump(store typing.KV, w io.Writer, opts ...*DumpOptions) (n int, err error) {
+	opt := &DumpOptions{}
+	if len(opts) > 0 && opts[0] != nil {
+		opt = opts[0]
+	}
+
+	if opt.Gzip {
+		gw := gzip.NewWriter(w)
+		defer func() {
+			if errX := gw.Close(); err == nil {
+				err = errX
+			}
+		}()
+		w = gw
+	}
+
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	if err := encoder.Encode(&DumpHeader{Version: DumpVersion}); err != nil {
+		return 0, err
+	}
+
+	ttl, _ := store.(typing.TTL)
+	for _, key := range KeysWithPrefix(store, opt.Prefix) {
+		e, err := dumpEntry(store, ttl, key)
+		if err != nil {
+			return n, fmt.Errorf("%s: %w", key, err)
+		}
+		if e == nil {
+			// expired since listed
+			continue
+		}
+
+		if err := encoder.Encode(e); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, bw.Flush()
+}
+
+// dumpEntry returns the entry of the key, or nil if it has expired.
+func dumpEntry(store typing.KV, ttl typing.TTL, key string) (*DumpEntry, error) {
+	e := &DumpEntry{Key: key, Codec: CodecJSON}
+	if ttl != nil {
+		d, err := ttl.TTL(key)
+		if errors.Is(err, typing.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d > 0 {
+			expiresAt := time.Now().Add(d).UTC()
+			e.ExpiresAt = &expiresAt
+		}
+	} else if !store.Has(key) {
+		return nil, nil
+	}
+
+	var value any
+	if err := store.Get(key, &value); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	e.Value = raw
+
+	return e, nil
+}
+
+// Restore reads a dump of Dump from r, which may be gzipped, into store,
+// and returns the number of restored keys. The entries already expired are skipped.
+func Restore(store typing.KV, r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		defer gr.Close()
+		r = gr
+	} else {
+		r = br
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errors.New("dump header is missing")
+	}
+
+	var header DumpHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return 0, fmt.Errorf("line 1: %w", err)
+	}
+	if header.Version < 1 || header.Version > DumpVersion {
+		return 0, fmt.Errorf("unsupported dump version %d", header.Version)
+	}
+
+	n := 0
+	for line := 2; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var e DumpEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		restored, err := restoreEntry(store, &e)
+		if err != nil {
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+		if restored {
+			n++
+		}
+	}
+
+	return n, scanner.Err()
+}
+
+// restoreEntry sets the entry in store, and returns false if it has expired.
+func restoreEntry(store typing.KV, e *DumpEntry) (bool, error) {
+	if e.Key == "" {
+		return false, errors.New("key is required")
+	}
+	if e.Codec != CodecJSON {
+		return false, fmt.Errorf("unsupported codec %q", e.Codec)
+	}
+
+	var maxAge []time.Duration
+	if e.ExpiresAt != nil {
+		d := time.Until(*e.ExpiresAt)
+		if d <= 0 {
+			return false, nil
+		}
+		maxAge = append(maxAge, d)
+	}
+
+	var value any
+	if err := json.Unmarshal(e.Value, &value); err != nil {
+		return false, err
+	}
+
+	return true, Replace(store, e.Key, pointerTo(value), maxAge...)
+}
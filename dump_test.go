@@ -0,0 +1,87 @@
+package kv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/memory"
+)
+
+func TestDumpRestore(t *testing.T) {
+	src, _ := fs.New(&fs.FileSystemOptions{Dir: t.TempDir()})
+	src.Set("user:1", map[string]any{"name": "zero"}, time.Hour)
+	src.Set("user:2", "text")
+	src.Set("config", 42)
+
+	for _, gz := range []bool{false, true} {
+		var buf bytes.Buffer
+		n, err := Dump(src, &buf, &DumpOptions{Prefix: "user:", Gzip: gz})
+		if err != nil || n != 2 {
+			t.Fatalf("Expected 2 dumped keys, got %d, %v", n, err)
+		}
+		if !gz && !strings.HasPrefix(buf.String(), `{"version":1}`+"\n") {
+			t.Errorf("Expected dump header, got %q", buf.String())
+		}
+
+		dst := memory.New()
+		n, err = Restore(dst, &buf)
+		if err != nil || n != 2 {
+			t.Fatalf("Expected 2 restored keys, got %d, %v", n, err)
+		}
+
+		var user map[string]any
+		if err := dst.Get("user:1", &user); err != nil || user["name"] != "zero" {
+			t.Errorf("Expected user 1, got %v, %v", user, err)
+		}
+		if d, err := dst.TTL("user:1"); err != nil || d <= 0 || d > time.Hour {
+			t.Errorf("Expected restored ttl, got %s, %v", d, err)
+		}
+		if d, err := dst.TTL("user:2"); err != nil || d != -1 {
+			t.Errorf("Expected no ttl, got %s, %v", d, err)
+		}
+		if dst.Has("config") {
+			t.Error("Expected config not to be dumped")
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	dump := `{"version":1}
+{"key":"a","value":[1,2],"codec":"json"}
+{"key":"b","value":"old","expiresAt":"2000-01-01T00:00:00Z","codec":"json"}
+`
+	dst := memory.New()
+	if n, err := Restore(dst, strings.NewReader(dump)); err != nil || n != 1 {
+		t.Fatalf("Expected 1 restored key, got %d, %v", n, err)
+	}
+	if dst.Has("b") {
+		t.Error("Expected expired entry to be skipped")
+	}
+
+	for _, invalid := range []string{
+		"",
+		`{"version":2}`,
+		`{"version":1}` + "\n" + `{"key":"a","value":"x","codec":"gob"}`,
+		`{"version":1}` + "\n" + `not json`,
+	} {
+		if _, err := Restore(dst, strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected restore of %q to fail", invalid)
+		}
+	}
+}
+
+func TestDumpTTLError(t *testing.T) {
+	store := memory.New()
+	for _, key := range []string{"a", "fail"} {
+		value := key
+		store.Set(key, &value)
+	}
+
+	var buf bytes.Buffer
+	if _, err := Dump(ttlFailingKV{store}, &buf); err == nil || !strings.Contains(err.Error(), "fail") {
+		t.Errorf("Expected the ttl error of fail, got %v", err)
+	}
+}
@@ -0,0 +1,388 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/go-zoox/kv/glob"
+)
+
+// batchSize is the max number of requests in a BatchWriteItem call.
+const batchSize = 25
+
+// batchRetries is the max number of retries for unprocessed items in a BatchWriteItem call.
+const batchRetries = 10
+
+// DynamoDB is a Key-Value Store in DynamoDB.
+type DynamoDB struct {
+	Core   *dynamodb.Client
+	Ctx    context.Context
+	Config *Config
+}
+
+// Config is the configuration for DynamoDB.
+type Config struct {
+	// Table is the table to use, which must exist,
+	// with a string partition key and TTL enabled on TTLAttribute.
+	Table string
+
+	// Region is the AWS region, such as us-east-1.
+	Region string
+	// Endpoint is the custom endpoint, such as http://localhost:8000 for DynamoDB Local.
+	Endpoint string
+
+	// AccessKeyID is the static access key id.
+	// If empty, the default AWS credential chain is used.
+	AccessKeyID string
+	// SecretAccessKey is the static secret access key.
+	SecretAccessKey string
+
+	// PartitionKey is the partition key attribute of the table, default is id.
+	PartitionKey string
+	// TTLAttribute is the TTL attribute of the table, default is expiresAt.
+	// It holds the expiry in unix seconds, as DynamoDB TTL requires.
+	TTLAttribute string
+
+	// Prefix is the prefix to use for all keys
+	Prefix string
+}
+
+// New returns a new DynamoDB.
+func New(cfg *Config) (*DynamoDB, error) {
+	if cfg.Table == "" {
+		return nil, errors.New("dynamodb table is required")
+	}
+	if cfg.PartitionKey == "" {
+		cfg.PartitionKey = "id"
+	}
+	if cfg.TTLAttribute == "" {
+		cfg.TTLAttribute = "expiresAt"
+	}
+
+	ctx := context.Background()
+
+	opts := []func(*config.LoadOptions) error{}
+	if cfg.Region != "" {
+		opts = append(opts, config.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	core := dynamodb.NewFromConfig(awsCfg, func(o *dynamodb.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+
+	return &DynamoDB{
+		Core:   core,
+		Ctx:    ctx,
+		Config: cfg,
+	}, nil
+}
+
+func (m *DynamoDB) getKey(key string) string {
+	return m.Config.Prefix + key
+}
+
+func (m *DynamoDB) itemKey(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		m.Config.PartitionKey: &types.AttributeValueMemberS{Value: m.getKey(key)},
+	}
+}
+
+// expiresAt returns the expiry of the item in unix seconds, or 0 if it never expires.
+func (m *DynamoDB) expiresAt(item map[string]types.AttributeValue) int64 {
+	attr, ok := item[m.Config.TTLAttribute].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0
+	}
+
+	expiresAt, _ := strconv.ParseInt(attr.Value, 10, 64)
+	return expiresAt
+}
+
+// expired reports whether the item has expired.
+// DynamoDB deletes expired items lazily, usually within a few days.
+func (m *DynamoDB) expired(item map[string]types.AttributeValue) bool {
+	expiresAt := m.expiresAt(item)
+	return expiresAt > 0 && !time.Now().Before(time.Unix(expiresAt, 0))
+}
+
+func (m *DynamoDB) getItem(key string) (map[string]types.AttributeValue, error) {
+	res, err := m.Core.GetItem(m.Ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(m.Config.Table),
+		Key:            m.itemKey(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Item, nil
+}
+
+// Set sets the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge,
+// rounded up to seconds.
+func (m *DynamoDB) Set(key string, value any, maxAge ...time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	item := m.itemKey(key)
+	item["value"] = &types.AttributeValueMemberB{Value: raw}
+
+	expiresAt := int64(0)
+	if len(maxAge) > 0 {
+		expiresAt = time.Now().Add(maxAge[0] + time.Second - 1).Unix()
+	} else {
+		old, err := m.getItem(key)
+		if err != nil {
+			return err
+		}
+
+		// use origin expiresAt
+		if old != nil && !m.expired(old) {
+			expiresAt = m.expiresAt(old)
+		}
+	}
+
+	if expiresAt > 0 {
+		item[m.Config.TTLAttribute] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt, 10)}
+	}
+
+	_, err = m.Core.PutItem(m.Ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(m.Config.Table),
+		Item:      item,
+	})
+	return err
+}
+
+// Replace sets the value for the given key, which never expires.
+// The item is put without the TTL attribute.
+func (m *DynamoDB) Replace(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	item := m.itemKey(key)
+	item["value"] = &types.AttributeValueMemberB{Value: raw}
+
+	_, err = m.Core.PutItem(m.Ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(m.Config.Table),
+		Item:      item,
+	})
+	return err
+}
+
+// Get returns the value for the given key.
+func (m *DynamoDB) Get(key string, value any) error {
+	item, err := m.getItem(key)
+	if err != nil {
+		return err
+	}
+
+	if item == nil {
+		return fmt.Errorf("key %s not found", key)
+	}
+
+	if m.expired(item) {
+		return fmt.Errorf("key %s expired", key)
+	}
+
+	raw, ok := item["value"].(*types.AttributeValueMemberB)
+	if !ok {
+		return fmt.Errorf("key %s has invalid value", key)
+	}
+
+	return json.Unmarshal(raw.Value, value)
+}
+
+// Delete deletes the value for the given key.
+func (m *DynamoDB) Delete(key string) error {
+	_, err := m.Core.DeleteItem(m.Ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(m.Config.Table),
+		Key:       m.itemKey(key),
+	})
+	return err
+}
+
+// Has returns true if the given key exists in the kv.
+func (m *DynamoDB) Has(key string) bool {
+	item, err := m.getItem(key)
+	if err != nil || item == nil {
+		return false
+	}
+
+	return !m.expired(item)
+}
+
+// scan returns the ids of the items with the configured prefix and the given one,
+// unexpired only unless withExpired, as DynamoDB deletes the expired items lazily.
+func (m *DynamoDB) scan(prefix string, withExpired bool) ([]string, error) {
+	prefix = m.getKey(prefix)
+	input := &dynamodb.ScanInput{
+		TableName:            aws.String(m.Config.Table),
+		ProjectionExpression: aws.String("#id, #ttl"),
+		ExpressionAttributeNames: map[string]string{
+			"#id":  m.Config.PartitionKey,
+			"#ttl": m.Config.TTLAttribute,
+		},
+		ConsistentRead: aws.Bool(true),
+	}
+	if prefix != "" {
+		input.FilterExpression = aws.String("begins_with(#id, :prefix)")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":prefix": &types.AttributeValueMemberS{Value: prefix},
+		}
+	}
+
+	ids := []string{}
+	paginator := dynamodb.NewScanPaginator(m.Core, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(m.Ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			if !withExpired && m.expired(item) {
+				continue
+			}
+
+			if id, ok := item[m.Config.PartitionKey].(*types.AttributeValueMemberS); ok {
+				ids = append(ids, id.Value)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// Keys returns the keys of the kv.
+func (m *DynamoDB) Keys() []string {
+	return m.KeysWithPrefix("")
+}
+
+// KeysWithPrefix returns the keys starting with the given prefix, by a filtered scan.
+func (m *DynamoDB) KeysWithPrefix(prefix string) []string {
+	ids, err := m.scan(prefix, false)
+	if err != nil {
+		return []string{}
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = id[len(m.Config.Prefix):]
+	}
+	return keys
+}
+
+// KeysMatching returns the keys matching the glob pattern,
+// of the keys with its literal prefix.
+func (m *DynamoDB) KeysMatching(pattern string) []string {
+	keys := []string{}
+	for _, key := range m.KeysWithPrefix(glob.Prefix(pattern)) {
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// Size returns the number of elements in the kv.
+func (m *DynamoDB) Size() int {
+	return len(m.Keys())
+}
+
+// Clear removes all elements from the kv.
+func (m *DynamoDB) Clear() error {
+	return m.DeletePrefix("")
+}
+
+// DeletePrefix deletes the keys starting with the given prefix, in batches,
+// including the expired items which DynamoDB has not deleted yet.
+func (m *DynamoDB) DeletePrefix(prefix string) error {
+	ids, err := m.scan(prefix, true)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		requests := make([]types.WriteRequest, 0, end-start)
+		for _, id := range ids[start:end] {
+			requests = append(requests, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{Key: m.itemKey(id[len(m.Config.Prefix):])},
+			})
+		}
+
+		if err := m.batchWrite(requests); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// batchWrite writes the requests, retrying the unprocessed ones.
+func (m *DynamoDB) batchWrite(requests []types.WriteRequest) error {
+	for retry := 0; len(requests) > 0; retry++ {
+		if retry > batchRetries {
+			return fmt.Errorf("dynamodb: %d items unprocessed after %d retries", len(requests), batchRetries)
+		}
+		if retry > 0 {
+			time.Sleep(time.Duration(retry) * 50 * time.Millisecond)
+		}
+
+		res, err := m.Core.BatchWriteItem(m.Ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				m.Config.Table: requests,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		requests = res.UnprocessedItems[m.Config.Table]
+	}
+
+	return nil
+}
+
+// ForEach calls the given function for each key-value pair in the kv.
+func (m *DynamoDB) ForEach(f func(string, interface{})) {
+	for _, key := range m.Keys() {
+		var value any
+		if err := m.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/go-zoox/kv/test"
+)
+
+// pageSize is the scan page size of the fake server, small enough to test pagination.
+const pageSize = 2
+
+type attributeValue = map[string]json.RawMessage
+
+type item = map[string]attributeValue
+
+// fakeServer is an in-process stand-in of the DynamoDB JSON API,
+// which supports the operations used by DynamoDB.
+type fakeServer struct {
+	sync.Mutex
+	items map[string]item
+}
+
+func (s *fakeServer) id(key item) string {
+	var id string
+	json.Unmarshal(key["id"]["S"], &id)
+	return id
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	var req struct {
+		Key                       item
+		Item                      item
+		ExclusiveStartKey         item
+		ExpressionAttributeValues item
+		RequestItems              map[string][]struct {
+			DeleteRequest struct {
+				Key item
+			}
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var res any = map[string]any{}
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.") {
+	case "GetItem":
+		if it, ok := s.items[s.id(req.Key)]; ok {
+			res = map[string]any{"Item": it}
+		}
+	case "PutItem":
+		s.items[s.id(req.Item)] = req.Item
+	case "DeleteItem":
+		delete(s.items, s.id(req.Key))
+	case "BatchWriteItem":
+		for _, requests := range req.RequestItems {
+			for _, r := range requests {
+				delete(s.items, s.id(r.DeleteRequest.Key))
+			}
+		}
+	case "Scan":
+		var prefix string
+		json.Unmarshal(req.ExpressionAttributeValues[":prefix"]["S"], &prefix)
+		start := s.id(req.ExclusiveStartKey)
+
+		ids := []string{}
+		for id := range s.items {
+			if strings.HasPrefix(id, prefix) && id > start {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+
+		page := map[string]any{}
+		if len(ids) > pageSize {
+			ids = ids[:pageSize]
+			page["LastEvaluatedKey"] = item{"id": s.items[ids[pageSize-1]]["id"]}
+		}
+
+		items := []item{}
+		for _, id := range ids {
+			items = append(items, s.items[id])
+		}
+		page["Items"] = items
+		page["Count"] = len(items)
+		res = page
+	default:
+		http.Error(w, "unsupported operation", http.StatusBadRequest)
+		return
+	}
+
+	body, _ := json.Marshal(res)
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.Header().Set("X-Amz-Crc32", strconv.FormatUint(uint64(crc32.ChecksumIEEE(body)), 10))
+	w.Write(body)
+}
+
+func createClient(t *testing.T, prefix string) *DynamoDB {
+	server := httptest.NewServer(&fakeServer{items: make(map[string]item)})
+	t.Cleanup(server.Close)
+
+	client, err := New(&Config{
+		Table:           "kv",
+		Region:          "us-east-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		Prefix:          prefix,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestKV(t *testing.T) {
+	test.RunTestCases(t, createClient(t, "go-zoox-test:"))
+}
+
+func TestScanPagination(t *testing.T) {
+	client := createClient(t, "")
+
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		if err := client.Set(key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if client.Size() != 5 {
+		t.Fatalf("Expected size 5, got %d", client.Size())
+	}
+
+	if err := client.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if client.Size() != 0 {
+		t.Errorf("Expected size 0, got %d", client.Size())
+	}
+}
+
+func TestClearExpired(t *testing.T) {
+	client := createClient(t, "")
+
+	// an expired item, which DynamoDB has not deleted yet
+	item := client.itemKey("expired")
+	item["value"] = &types.AttributeValueMemberB{Value: []byte(`"value"`)}
+	item["expiresAt"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)}
+	if _, err := client.Core.PutItem(client.Ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(client.Config.Table),
+		Item:      item,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.Has("expired") {
+		t.Fatal("Expected the item to be expired")
+	}
+	if err := client.Clear(); err != nil {
+		t.Fatal(err)
+	}
+
+	if old, err := client.getItem("expired"); err != nil || old != nil {
+		t.Errorf("Expected the expired item deleted, got %v, %v", old, err)
+	}
+}
@@ -0,0 +1,173 @@
+package kv
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-zoox/dotenv"
+	"github.com/go-zoox/kv/typing"
+)
+
+// envNames are the names of the engines in the variables, if not the upper case name.
+var envNames = map[string]string{
+	"filesystem": "FS",
+}
+
+// NewFromEnv returns a new KV configured by the environment variables,
+// and the .env file in the working directory if any, see ConfigFromEnv.
+func NewFromEnv(prefix string) (KV, error) {
+	cfg, err := ConfigFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(cfg)
+}
+
+// ConfigFromEnv reads the Config from the environment variables with the prefix, default is KV:
+//
+//	KV_DSN, such as redis://localhost:6379/0?prefix=app:, which takes precedence
+//	KV_ENGINE, default is memory
+//	KV_<ENGINE>_<FIELD>, such as KV_REDIS_URI, KV_REDIS_PREFIX, KV_FS_DIR or KV_MEMORY_MAX_SIZE
+//
+// The fields are those of the engine config struct in upper snake case,
+// durations are parsed by time.ParseDuration, such as 30s.
+// The variables of the .env file in the working directory do not override the environment.
+func ConfigFromEnv(prefix string) (*typing.Config, error) {
+	if prefix == "" {
+		prefix = "KV"
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+
+	if err := loadDotEnv(); err != nil {
+		return nil, err
+	}
+
+	if dsn := dotenv.Get(prefix + "DSN"); dsn != "" {
+		return ParseDSN(dsn)
+	}
+
+	engine := dotenv.Get(prefix+"ENGINE", "memory")
+	if _, ok := lookup(engine); !ok {
+		return nil, NewError(ErrUnknownEngine, fmt.Sprintf("%s, available engines are %s", engine, strings.Join(Engines(), ", ")))
+	}
+
+	cfg := &typing.Config{Engine: engine}
+	config, ok := newConfig(engine)
+	if !ok {
+		// registered engines get no config
+		return cfg, nil
+	}
+
+	name, ok := envNames[engine]
+	if !ok {
+		name = strings.ToUpper(strings.ReplaceAll(engine, "-", "_"))
+	}
+	if err := decodeEnv(config, prefix+name+"_"); err != nil {
+		return nil, err
+	}
+
+	cfg.Config = config
+	return cfg, nil
+}
+
+func loadDotEnv() error {
+	if _, err := os.Stat(".env"); err != nil {
+		return nil
+	}
+
+	return dotenv.Load(&struct{}{})
+}
+
+// decodeEnv sets the fields of the struct pointed by v from the variables,
+// the fields of unsupported types are skipped, such as *sql.DB.
+func decodeEnv(v any, prefix string) error {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := prefix + snakeCase(field.Name)
+		raw := dotenv.Get(name)
+		if raw == "" {
+			continue
+		}
+
+		if err := setField(rv.Field(i), raw); err != nil {
+			return NewError(ErrInvalidEnv, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	return nil
+}
+
+func setField(f reflect.Value, raw string) error {
+	if f.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	}
+
+	return nil
+}
+
+// snakeCase converts a field name into upper snake case, such as AccessKeyID into ACCESS_KEY_ID.
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
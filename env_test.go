@@ -0,0 +1,128 @@
+package kv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/bitcask"
+	"github.com/go-zoox/kv/dynamodb"
+	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/redis"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	cfg, err := ConfigFromEnv("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Engine != "memory" {
+		t.Errorf("Expected default engine memory, got %s", cfg.Engine)
+	}
+
+	t.Setenv("KV_ENGINE", "redis")
+	t.Setenv("KV_REDIS_URI", "redis://localhost:6379/0")
+	t.Setenv("KV_REDIS_PREFIX", "app:")
+	t.Setenv("KV_REDIS_DB", "2")
+	cfg, err = ConfigFromEnv("KV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := cfg.Config.(*redis.Config); c.URI != "redis://localhost:6379/0" || c.Prefix != "app:" || c.DB != 2 {
+		t.Errorf("Unexpected redis config %+v", c)
+	}
+
+	t.Setenv("APP_CACHE_ENGINE", "filesystem")
+	t.Setenv("APP_CACHE_FS_DIR", "/tmp/app")
+	cfg, err = ConfigFromEnv("APP_CACHE_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := cfg.Config.(*fs.FileSystemOptions); c.Dir != "/tmp/app" {
+		t.Errorf("Unexpected fs config %+v", c)
+	}
+
+	t.Setenv("KV_ENGINE", "bitcask")
+	t.Setenv("KV_BITCASK_MERGE_INTERVAL", "1m")
+	t.Setenv("KV_BITCASK_MERGE_RATIO", "0.25")
+	cfg, err = ConfigFromEnv("KV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := cfg.Config.(*bitcask.Config); c.MergeInterval != time.Minute || c.MergeRatio != 0.25 {
+		t.Errorf("Unexpected bitcask config %+v", c)
+	}
+
+	t.Setenv("KV_ENGINE", "dynamodb")
+	t.Setenv("KV_DYNAMODB_ACCESS_KEY_ID", "id")
+	t.Setenv("KV_DYNAMODB_TTL_ATTRIBUTE", "ttl")
+	cfg, err = ConfigFromEnv("KV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := cfg.Config.(*dynamodb.Config); c.AccessKeyID != "id" || c.TTLAttribute != "ttl" {
+		t.Errorf("Unexpected dynamodb config %+v", c)
+	}
+
+	t.Setenv("KV_DSN", "memory://?max=10")
+	cfg, err = ConfigFromEnv("KV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := cfg.Config.(*memory.Config); cfg.Engine != "memory" || c.MaxSize != 10 {
+		t.Errorf("Expected DSN to take precedence, got %+v", cfg)
+	}
+}
+
+func TestConfigFromEnvInvalid(t *testing.T) {
+	t.Setenv("KV_ENGINE", "unknown")
+	if _, err := ConfigFromEnv("KV"); err == nil {
+		t.Error("Expected unknown engine to be an error")
+	}
+
+	t.Setenv("KV_ENGINE", "memory")
+	t.Setenv("KV_MEMORY_MAX_SIZE", "many")
+	if _, err := ConfigFromEnv("KV"); err == nil {
+		t.Error("Expected invalid max size to be an error")
+	}
+}
+
+func TestNewFromEnvDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("KV_ENGINE=memory\nKV_MEMORY_MAX_SIZE=3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	defer os.Unsetenv("KV_ENGINE")
+	defer os.Unsetenv("KV_MEMORY_MAX_SIZE")
+
+	client, err := NewFromEnv("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := client.(*memory.Memory); m.Config.MaxSize != 3 {
+		t.Errorf("Expected max size 3 from .env, got %d", m.Config.MaxSize)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Dir":             "DIR",
+		"URI":             "URI",
+		"MaxSize":         "MAX_SIZE",
+		"AccessKeyID":     "ACCESS_KEY_ID",
+		"TTLAttribute":    "TTL_ATTRIBUTE",
+		"CleanupInterval": "CLEANUP_INTERVAL",
+	} {
+		if got := snakeCase(name); got != expected {
+			t.Errorf("Expected snakeCase(%s) to be %s, got %s", name, expected, got)
+		}
+	}
+}
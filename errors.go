@@ -8,6 +8,15 @@ const ErrUnknownEngine = "unknown engine: %s"
 // ErrConfigNotSet means config not set error.
 const ErrConfigNotSet = "%s config not set"
 
+// ErrConfigInvalid means config of the wrong type error.
+const ErrConfigInvalid = "invalid config: %s"
+
+// ErrInvalidDSN means invalid dsn error.
+const ErrInvalidDSN = "invalid dsn: %s"
+
+// ErrInvalidEnv means invalid environment variable error.
+const ErrInvalidEnv = "invalid env: %s"
+
 // Error is the error type for KV.
 type Error struct {
 	Type    string
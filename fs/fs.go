@@ -1,13 +1,17 @@
 package fs
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
-	"reflect"
+	"strings"
 	"sync"
 	"time"
 
 	zfs "github.com/go-zoox/fs"
 	zjson "github.com/go-zoox/fs/type/json"
+	"github.com/go-zoox/kv/glob"
+	"github.com/go-zoox/kv/typing"
 )
 
 // FileSystem is a Key-Value Store in FileSystem，like JavaScript Map for Go
@@ -16,9 +20,10 @@ type FileSystem struct {
 	dir string
 }
 
-// Value is a value of Memory
+// Value is a value of FileSystem.
+// Value holds the raw JSON of the stored data, which is decoded into the caller's target on Get.
 type Value struct {
-	Value     any
+	Value     json.RawMessage
 	ExpiresAt int64
 }
 
@@ -74,7 +79,13 @@ func (m *FileSystem) Set(key string, value any, maxAge ...time.Duration) error {
 		m.Lock()
 	}
 
-	if err := m.write(key, &Value{value, expiresAt}); err != nil {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		m.Unlock()
+		return err
+	}
+
+	if err := m.write(key, &Value{raw, expiresAt}); err != nil {
 		m.Unlock()
 		return err
 	}
@@ -83,6 +94,21 @@ func (m *FileSystem) Set(key string, value any, maxAge ...time.Duration) error {
 	return nil
 }
 
+// Replace sets the value for the given key, which never expires.
+func (m *FileSystem) Replace(key string, value any) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.ensureDir()
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return m.write(key, &Value{raw, 0})
+}
+
 // Get returns the value for the given key.
 func (m *FileSystem) Get(key string, value interface{}) error {
 	m.RLock()
@@ -100,9 +126,24 @@ func (m *FileSystem) Get(key string, value interface{}) error {
 		return nil
 	}
 
-	// reflect valueof
-	reflect.ValueOf(value).Elem().Set(reflect.ValueOf(v.Value))
-	return nil
+	return json.Unmarshal(v.Value, value)
+}
+
+// TTL returns the remaining time to live of the given key, or -1 if it never expires.
+func (m *FileSystem) TTL(key string) (time.Duration, error) {
+	m.RLock()
+	v := m.read(key)
+	m.RUnlock()
+
+	if v == nil || (v.ExpiresAt > 0 && v.ExpiresAt < now()) {
+		return 0, fmt.Errorf("key %s %w", key, typing.ErrNotFound)
+	}
+
+	if v.ExpiresAt == 0 {
+		return -1, nil
+	}
+
+	return time.Duration(v.ExpiresAt-now()) * time.Millisecond, nil
 }
 
 // Delete deletes the value for the given key.
@@ -150,6 +191,59 @@ func (m *FileSystem) Keys() []string {
 	return keys
 }
 
+// KeysWithPrefix returns the keys starting with the given prefix.
+func (m *FileSystem) KeysWithPrefix(prefix string) []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.keysWithPrefix(prefix)
+}
+
+// KeysMatching returns the keys matching the glob pattern.
+func (m *FileSystem) KeysMatching(pattern string) []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	keys := []string{}
+	for _, key := range m.keysWithPrefix(glob.Prefix(pattern)) {
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// DeletePrefix deletes the keys starting with the given prefix.
+func (m *FileSystem) DeletePrefix(prefix string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, key := range m.keysWithPrefix(prefix) {
+		if err := m.remove(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *FileSystem) keysWithPrefix(prefix string) []string {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return []string{}
+	}
+
+	keys := []string{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), prefix) {
+			keys = append(keys, e.Name())
+		}
+	}
+
+	return keys
+}
+
 // Size returns the number of elements in the kv.
 func (m *FileSystem) Size() int {
 	m.RLock()
@@ -184,8 +278,15 @@ func (m *FileSystem) ForEach(f func(string, interface{})) {
 	for _, file := range files {
 		k := file.Name()
 		v := m.read(k)
-		if v != nil {
-			f(k, v.Value)
+		if v == nil {
+			continue
+		}
+
+		var value any
+		if err := json.Unmarshal(v.Value, &value); err != nil {
+			f(k, nil)
+		} else {
+			f(k, value)
 		}
 	}
 }
@@ -208,8 +309,13 @@ func (m *FileSystem) write(key string, v *Value) error {
 		zfs.CreateFile(filepath)
 	}
 
-	// fmt.Println("write file:", filepath, v)
-	return zjson.Write(filepath, v)
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// os.WriteFile truncates the file, which may hold a longer value
+	return os.WriteFile(filepath, raw, 0644)
 }
 
 func (m *FileSystem) read(key string) *Value {
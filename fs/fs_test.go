@@ -2,6 +2,7 @@ package fs
 
 import (
 	"testing"
+	"time"
 
 	"github.com/go-zoox/kv/test"
 )
@@ -19,3 +20,19 @@ func TestKV(t *testing.T) {
 	// client.Get("key", &v)
 	// fmt.Println("v:", v)
 }
+
+func TestOverwriteShorter(t *testing.T) {
+	client, _ := New(&FileSystemOptions{Dir: t.TempDir()})
+
+	if err := client.Set("key", "a much longer value than the next one", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Set("key", "short", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var value string
+	if err := client.Get("key", &value); err != nil || value != "short" {
+		t.Errorf("Expected value to be 'short', got %q, %v", value, err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package glob matches keys against redis glob patterns, for the engines without native pattern matching.
+package glob
+
+import "strings"
+
+// Match reports whether s matches the redis glob pattern,
+// which supports *, ?, [abc], [^abc], [a-z] and \ to escape.
+//
+// It backtracks only to the last *, so it runs in O(len(pattern) * len(s)).
+func Match(pattern, s string) bool {
+	p, i := 0, 0
+	// the position after the last *, and the position in s it matches up to
+	star, starI := -1, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				for p < len(pattern) && pattern[p] == '*' {
+					p++
+				}
+				if p == len(pattern) {
+					return true
+				}
+
+				star, starI = p, i
+				continue
+
+			case '?':
+				p++
+				i++
+				continue
+
+			case '[':
+				if end, ok := matchClass(pattern[p:], s[i]); ok {
+					p += end
+					i++
+					continue
+				}
+
+			default:
+				c, n := pattern[p], 1
+				if c == '\\' && p+1 < len(pattern) {
+					c, n = pattern[p+1], 2
+				}
+				if s[i] == c {
+					p += n
+					i++
+					continue
+				}
+			}
+		}
+
+		// mismatch, let the last * match one more byte
+		if star < 0 {
+			return false
+		}
+		starI++
+		p, i = star, starI
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
+
+// matchClass matches c against the class at the start of pattern,
+// it returns the length of the class and whether c matches.
+func matchClass(pattern string, c byte) (int, bool) {
+	i := 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+
+	matched := false
+	for i < len(pattern) && pattern[i] != ']' {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			if pattern[i] == c {
+				matched = true
+			}
+			i++
+		case i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']':
+			lo, hi := pattern[i], pattern[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			i += 3
+		default:
+			if pattern[i] == c {
+				matched = true
+			}
+			i++
+		}
+	}
+
+	// an unterminated class runs to the end of the pattern, like redis
+	if i < len(pattern) {
+		i++
+	}
+
+	return i, matched != negate
+}
+
+// Prefix returns the literal prefix of pattern, before its first special character, unescaped.
+// The keys matching pattern all start with it, so it narrows the keys to match.
+func Prefix(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return b.String()
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+
+		b.WriteByte(pattern[i])
+	}
+
+	return b.String()
+}
+
+// Escape escapes the special characters of s, so that it matches itself only.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
@@ -0,0 +1,72 @@
+package glob
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	for _, c := range []struct {
+		pattern, s string
+		matched    bool
+	}{
+		{"*", "anything", true},
+		{"user:*", "user:1", true},
+		{"user:*", "session:1", false},
+		{"h?llo", "hello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"a/*", "a/b/c", true},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b*c", "aXbYbZ", false},
+		{"*[0-9]", "key1", true},
+		{"", "", true},
+		{"?", "", false},
+		{"a\\", "a\\", true},
+	} {
+		if Match(c.pattern, c.s) != c.matched {
+			t.Errorf("Expected Match(%q, %q) to be %v", c.pattern, c.s, c.matched)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	for pattern, prefix := range map[string]string{
+		"user:*":      "user:",
+		"user:?:name": "user:",
+		"[ab]*":       "",
+		`a\*b*`:       "a*b",
+		"plain":       "plain",
+	} {
+		if got := Prefix(pattern); got != prefix {
+			t.Errorf("Expected Prefix(%q) to be %q, got %q", pattern, prefix, got)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, s := range []string{"plain", "a*b", "what?", `[x]\y`} {
+		if !Match(Escape(s), s) || Match(Escape(s), s+"x") {
+			t.Errorf("Expected Escape(%q) to match itself only, got %q", s, Escape(s))
+		}
+		if Prefix(Escape(s)+"*") != s {
+			t.Errorf("Expected the prefix of Escape(%q) to be itself", s)
+		}
+	}
+}
+
+func TestMatchBacktracking(t *testing.T) {
+	s := strings.Repeat("a", 40)
+
+	start := time.Now()
+	if Match("*a*a*a*a*a*a*a*a*b", s) {
+		t.Error("Expected no match")
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("Expected linear backtracking, took %s", d)
+	}
+}
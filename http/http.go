@@ -0,0 +1,251 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	gohttp "net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+)
+
+// headerTTL is the header to set the max age of a value, see server/http.
+const headerTTL = "X-KV-TTL"
+
+// errEmptyKey is returned for an empty key, whose URL would be the one of all keys.
+var errEmptyKey = errors.New("http: key is required")
+
+// HTTP is a Key-Value Store on a remote REST server, see server/http.
+type HTTP struct {
+	Core   *gohttp.Client
+	Config *Config
+}
+
+// Config is the configuration for HTTP.
+type Config struct {
+	// URL is the base URL of the server, such as http://localhost:8080
+	URL string
+
+	// Headers are extra headers sent with each request, such as Authorization.
+	Headers map[string]string
+
+	// Timeout is the timeout of each request, default is 10 seconds.
+	Timeout time.Duration
+}
+
+// New returns a new HTTP.
+func New(cfg *Config) (*HTTP, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("http URL is required")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &HTTP{
+		Core:   &gohttp.Client{Timeout: cfg.Timeout},
+		Config: cfg,
+	}, nil
+}
+
+func (m *HTTP) keyURL(key string) string {
+	return strings.TrimSuffix(m.Config.URL, "/") + "/keys/" + url.PathEscape(key)
+}
+
+func (m *HTTP) keysURL() string {
+	return strings.TrimSuffix(m.Config.URL, "/") + "/keys"
+}
+
+func (m *HTTP) do(method string, url string, body []byte, headers map[string]string) (*gohttp.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := gohttp.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range m.Config.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return m.Core.Do(req)
+}
+
+func checkStatus(resp *gohttp.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	message, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("http: unexpected status %s: %s", resp.Status, strings.TrimSpace(string(message)))
+}
+
+// Set sets the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge miliseconds.
+func (m *HTTP) Set(key string, value any, maxAge ...time.Duration) error {
+	if key == "" {
+		return errEmptyKey
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{}
+	if len(maxAge) > 0 && maxAge[0] > 0 {
+		headers[headerTTL] = maxAge[0].String()
+	}
+
+	resp, err := m.do(gohttp.MethodPut, m.keyURL(key), raw, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp)
+}
+
+// Get returns the value for the given key.
+func (m *HTTP) Get(key string, value any) error {
+	if key == "" {
+		return errEmptyKey
+	}
+
+	resp, err := m.do(gohttp.MethodGet, m.keyURL(key), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == gohttp.StatusNotFound {
+		return fmt.Errorf("key %s not found", key)
+	}
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+// Delete deletes the value for the given key.
+func (m *HTTP) Delete(key string) error {
+	if key == "" {
+		return errEmptyKey
+	}
+
+	resp, err := m.do(gohttp.MethodDelete, m.keyURL(key), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp)
+}
+
+// Has returns true if the given key exists in the kv.
+func (m *HTTP) Has(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	resp, err := m.do(gohttp.MethodHead, m.keyURL(key), nil, nil)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == gohttp.StatusOK
+}
+
+// Keys returns the keys of the kv.
+func (m *HTTP) Keys() []string {
+	return m.KeysWithPrefix("")
+}
+
+// KeysWithPrefix returns the keys starting with the given prefix.
+func (m *HTTP) KeysWithPrefix(prefix string) []string {
+	u := m.keysURL()
+	if prefix != "" {
+		u += "?prefix=" + url.QueryEscape(prefix)
+	}
+
+	resp, err := m.do(gohttp.MethodGet, u, nil, nil)
+	if err != nil {
+		return []string{}
+	}
+	defer resp.Body.Close()
+
+	keys := []string{}
+	if checkStatus(resp) != nil || json.NewDecoder(resp.Body).Decode(&keys) != nil {
+		return []string{}
+	}
+
+	return keys
+}
+
+// KeysMatching returns the keys matching the glob pattern,
+// of the keys with its literal prefix, as the server has no pattern query.
+func (m *HTTP) KeysMatching(pattern string) []string {
+	keys := []string{}
+	for _, key := range m.KeysWithPrefix(glob.Prefix(pattern)) {
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// DeletePrefix deletes the keys starting with the given prefix, one request per key.
+func (m *HTTP) DeletePrefix(prefix string) error {
+	for _, key := range m.KeysWithPrefix(prefix) {
+		if err := m.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Size returns the number of elements in the kv.
+func (m *HTTP) Size() int {
+	return len(m.Keys())
+}
+
+// Clear removes all elements from the kv.
+func (m *HTTP) Clear() error {
+	resp, err := m.do(gohttp.MethodDelete, m.keysURL(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp)
+}
+
+// ForEach calls the given function for each key-value pair in the kv.
+func (m *HTTP) ForEach(f func(string, interface{})) {
+	for _, key := range m.Keys() {
+		var value any
+		if err := m.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-zoox/kv/memory"
+	server "github.com/go-zoox/kv/server/http"
+	"github.com/go-zoox/kv/test"
+)
+
+func createClient(t *testing.T) *HTTP {
+	s := httptest.NewServer(server.New(memory.New()))
+	t.Cleanup(s.Close)
+
+	client, err := New(&Config{URL: s.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestKV(t *testing.T) {
+	test.RunTestCases(t, createClient(t))
+}
+
+func TestKeysWithPrefix(t *testing.T) {
+	client := createClient(t)
+
+	for _, key := range []string{"user/2", "session/1", "user/1"} {
+		if err := client.Set(key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := client.KeysWithPrefix("user/")
+	if strings.Join(keys, ",") != "user/1,user/2" {
+		t.Errorf("Expected keys user/1,user/2, got %v", keys)
+	}
+
+	var value string
+	if err := client.Get("user/1", &value); err != nil || value != "user/1" {
+		t.Errorf("Expected value to be 'user/1', got %s", value)
+	}
+}
+
+func TestEmptyKey(t *testing.T) {
+	client := createClient(t)
+
+	if err := client.Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Delete(""); err == nil {
+		t.Error("Expected empty key to be an error")
+	}
+	if err := client.Set("", "value"); err == nil {
+		t.Error("Expected empty key to be an error")
+	}
+	if client.Has("") {
+		t.Error("Expected empty key not to exist")
+	}
+	if !client.Has("key") {
+		t.Error("Expected key to be kept")
+	}
+}
@@ -0,0 +1,430 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+)
+
+// JSONRPC is a Key-Value Store on a remote Server, over JSON-RPC 2.0.
+type JSONRPC struct {
+	sync.Mutex
+	Config *Config
+
+	http *http.Client
+
+	conn    net.Conn
+	decoder *json.Decoder
+	id      int64
+}
+
+// Config is the configuration for JSONRPC.
+type Config struct {
+	// URL is the URL of the server,
+	// such as http://localhost:8080/rpc or tcp://localhost:8081
+	URL string
+
+	// Token is the auth token of the server.
+	Token string
+
+	// Timeout is the timeout of each call, default is 10 seconds.
+	Timeout time.Duration
+}
+
+// Call is a single call in a batch.
+type Call struct {
+	Method string
+	Params any
+	// Result is a pointer to decode the result into, or nil to discard it.
+	Result any
+	// Error is the error of the call, set by Batch.
+	Error error
+}
+
+// New returns a new JSONRPC.
+func New(cfg *Config) (*JSONRPC, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("jsonrpc URL is required")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &JSONRPC{
+		Config: cfg,
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		m.http = &http.Client{Timeout: cfg.Timeout}
+	case "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("jsonrpc tcp host is required")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported jsonrpc URL scheme: %s", u.Scheme)
+	}
+
+	return m, nil
+}
+
+// Close closes the TCP connection, if any.
+func (m *JSONRPC) Close() error {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.closeConn()
+}
+
+func (m *JSONRPC) closeConn() error {
+	if m.conn == nil {
+		return nil
+	}
+
+	err := m.conn.Close()
+	m.conn = nil
+	m.decoder = nil
+	return err
+}
+
+// Call calls the method with params, and decodes the result into result, if not nil.
+func (m *JSONRPC) Call(method string, params any, result any) error {
+	call := &Call{Method: method, Params: params, Result: result}
+	if err := m.Batch(call); err != nil {
+		return err
+	}
+
+	return call.Error
+}
+
+// Batch sends the calls in a single batch request.
+// The returned error is a transport error, the error of each call is set in Call.Error.
+func (m *JSONRPC) Batch(calls ...*Call) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	requests := make([]*Request, len(calls))
+	for i, call := range calls {
+		var params json.RawMessage
+		if call.Params != nil {
+			raw, err := json.Marshal(call.Params)
+			if err != nil {
+				return err
+			}
+			params = raw
+		}
+
+		m.id++
+		requests[i] = &Request{
+			JSONRPC: Version,
+			Method:  call.Method,
+			Params:  params,
+			ID:      json.RawMessage(strconv.FormatInt(m.id, 10)),
+		}
+	}
+
+	var responses []*Response
+	var err error
+	if m.http != nil {
+		responses, err = m.roundTripHTTP(requests)
+	} else {
+		responses, err = m.roundTripTCP(requests)
+	}
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*Response, len(responses))
+	for _, res := range responses {
+		byID[string(res.ID)] = res
+	}
+
+	for i, call := range calls {
+		res, ok := byID[string(requests[i].ID)]
+		if !ok {
+			call.Error = fmt.Errorf("jsonrpc: no response for %s", call.Method)
+			continue
+		}
+
+		if res.Error != nil {
+			call.Error = res.Error
+			continue
+		}
+
+		if call.Result != nil {
+			call.Error = json.Unmarshal(res.Result, call.Result)
+		}
+	}
+
+	return nil
+}
+
+func encodeRequests(requests []*Request) ([]byte, error) {
+	if len(requests) == 1 {
+		return json.Marshal(requests[0])
+	}
+
+	return json.Marshal(requests)
+}
+
+func decodeResponses(raw json.RawMessage) ([]*Response, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var responses []*Response
+		err := json.Unmarshal(raw, &responses)
+		return responses, err
+	}
+
+	var res Response
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	// an error without id means the whole request was rejected
+	if res.Error != nil && string(res.ID) == "null" {
+		return nil, res.Error
+	}
+
+	return []*Response{&res}, nil
+}
+
+func (m *JSONRPC) roundTripHTTP(requests []*Request) ([]*Response, error) {
+	body, err := encodeRequests(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, m.Config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.Config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.Config.Token)
+	}
+
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jsonrpc: unexpected status %s", resp.Status)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	return decodeResponses(raw)
+}
+
+func (m *JSONRPC) roundTripTCP(requests []*Request) ([]*Response, error) {
+	if err := m.dial(); err != nil {
+		return nil, err
+	}
+
+	responses, err := m.exchange(requests)
+	if err != nil {
+		// the connection state is unknown, reconnect on next call
+		m.closeConn()
+		return nil, err
+	}
+
+	return responses, nil
+}
+
+func (m *JSONRPC) exchange(requests []*Request) ([]*Response, error) {
+	body, err := encodeRequests(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	m.conn.SetDeadline(time.Now().Add(m.Config.Timeout))
+	if _, err := m.conn.Write(append(body, '\n')); err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if err := m.decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	return decodeResponses(raw)
+}
+
+// dial connects to the server, and authenticates the connection if a token is set.
+func (m *JSONRPC) dial() error {
+	if m.conn != nil {
+		return nil
+	}
+
+	u, _ := url.Parse(m.Config.URL)
+	conn, err := net.DialTimeout("tcp", u.Host, m.Config.Timeout)
+	if err != nil {
+		return err
+	}
+
+	m.conn = conn
+	m.decoder = json.NewDecoder(bufio.NewReader(conn))
+
+	if m.Config.Token == "" {
+		return nil
+	}
+
+	params, _ := json.Marshal(&AuthParams{Token: m.Config.Token})
+	m.id++
+	responses, err := m.exchange([]*Request{{
+		JSONRPC: Version,
+		Method:  MethodAuth,
+		Params:  params,
+		ID:      json.RawMessage(strconv.FormatInt(m.id, 10)),
+	}})
+	if err == nil && responses[0].Error != nil {
+		err = responses[0].Error
+	}
+	if err != nil {
+		m.closeConn()
+		return err
+	}
+
+	return nil
+}
+
+// Set sets the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge miliseconds.
+func (m *JSONRPC) Set(key string, value any, maxAge ...time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	params := &SetParams{Key: key, Value: raw}
+	if len(maxAge) > 0 {
+		ms := int64(maxAge[0] / time.Millisecond)
+		params.MaxAge = &ms
+	}
+
+	return m.Call(MethodSet, params, nil)
+}
+
+// Get returns the value for the given key.
+func (m *JSONRPC) Get(key string, value any) error {
+	return m.Call(MethodGet, &KeyParams{Key: key}, value)
+}
+
+// Delete deletes the value for the given key.
+func (m *JSONRPC) Delete(key string) error {
+	return m.Call(MethodDelete, &KeyParams{Key: key}, nil)
+}
+
+// Has returns true if the given key exists in the kv.
+func (m *JSONRPC) Has(key string) bool {
+	var has bool
+	if err := m.Call(MethodHas, &KeyParams{Key: key}, &has); err != nil {
+		return false
+	}
+
+	return has
+}
+
+// Keys returns the keys of the kv.
+func (m *JSONRPC) Keys() []string {
+	keys := []string{}
+	if err := m.Call(MethodKeys, nil, &keys); err != nil {
+		return []string{}
+	}
+
+	return keys
+}
+
+// KeysWithPrefix returns the keys starting with the given prefix, filtered from kv.keys.
+func (m *JSONRPC) KeysWithPrefix(prefix string) []string {
+	return m.keys(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// KeysMatching returns the keys matching the glob pattern, filtered from kv.keys.
+func (m *JSONRPC) KeysMatching(pattern string) []string {
+	return m.keys(func(key string) bool { return glob.Match(pattern, key) })
+}
+
+func (m *JSONRPC) keys(match func(key string) bool) []string {
+	keys := []string{}
+	for _, key := range m.Keys() {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// DeletePrefix deletes the keys starting with the given prefix, in a single batch request.
+func (m *JSONRPC) DeletePrefix(prefix string) error {
+	keys := m.KeysWithPrefix(prefix)
+	calls := make([]*Call, len(keys))
+	for i, key := range keys {
+		calls[i] = &Call{Method: MethodDelete, Params: &KeyParams{Key: key}}
+	}
+
+	if err := m.Batch(calls...); err != nil {
+		return err
+	}
+
+	for _, call := range calls {
+		if call.Error != nil {
+			return call.Error
+		}
+	}
+
+	return nil
+}
+
+// Size returns the number of elements in the kv.
+func (m *JSONRPC) Size() int {
+	var size int
+	if err := m.Call(MethodSize, nil, &size); err != nil {
+		return 0
+	}
+
+	return size
+}
+
+// Clear removes all elements from the kv.
+func (m *JSONRPC) Clear() error {
+	return m.Call(MethodClear, nil, nil)
+}
+
+// ForEach calls the given function for each key-value pair in the kv.
+func (m *JSONRPC) ForEach(f func(string, interface{})) {
+	for _, key := range m.Keys() {
+		var value any
+		if err := m.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package jsonrpc
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/test"
+)
+
+func createHTTPClient(t *testing.T, serverToken, token string) *JSONRPC {
+	server := httptest.NewServer(NewServer(memory.New(), &ServerConfig{Token: serverToken}))
+	t.Cleanup(server.Close)
+
+	client, err := New(&Config{URL: server.URL, Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func createTCPClient(t *testing.T, serverToken, token string) *JSONRPC {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go NewServer(memory.New(), &ServerConfig{Token: serverToken}).Serve(l)
+
+	client, err := New(&Config{URL: "tcp://" + l.Addr().String(), Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestHTTP(t *testing.T) {
+	test.RunTestCases(t, createHTTPClient(t, "secret", "secret"))
+}
+
+func TestTCP(t *testing.T) {
+	test.RunTestCases(t, createTCPClient(t, "secret", "secret"))
+}
+
+func TestUnauthorized(t *testing.T) {
+	for name, client := range map[string]*JSONRPC{
+		"http": createHTTPClient(t, "secret", "wrong"),
+		"tcp":  createTCPClient(t, "secret", ""),
+	} {
+		err := client.Set("key", "value")
+		if e, ok := err.(*Error); !ok || e.Code != CodeUnauthorized {
+			t.Errorf("%s: expected unauthorized error, got %v", name, err)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	client := createTCPClient(t, "", "")
+
+	var value string
+	var size int
+	calls := []*Call{
+		{Method: MethodSet, Params: &SetParams{Key: "key", Value: []byte(`"value"`)}},
+		{Method: MethodGet, Params: &KeyParams{Key: "key"}, Result: &value},
+		{Method: MethodGet, Params: &KeyParams{Key: "missing"}},
+		{Method: MethodSize, Result: &size},
+		{Method: "kv.unknown"},
+	}
+	if err := client.Batch(calls...); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls[0].Error != nil || calls[1].Error != nil || calls[3].Error != nil {
+		t.Fatalf("Expected no error, got %v, %v, %v", calls[0].Error, calls[1].Error, calls[3].Error)
+	}
+	if value != "value" || size != 1 {
+		t.Errorf("Expected value 'value' and size 1, got %s and %d", value, size)
+	}
+	if e, ok := calls[2].Error.(*Error); !ok || e.Code != CodeKVError {
+		t.Errorf("Expected kv error, got %v", calls[2].Error)
+	}
+	if e, ok := calls[4].Error.(*Error); !ok || e.Code != CodeMethodNotFound {
+		t.Errorf("Expected method not found error, got %v", calls[4].Error)
+	}
+}
@@ -0,0 +1,81 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Version is the JSON-RPC version.
+const Version = "2.0"
+
+// Methods exposed by the Server.
+const (
+	MethodAuth   = "auth"
+	MethodSet    = "kv.set"
+	MethodGet    = "kv.get"
+	MethodDelete = "kv.delete"
+	MethodHas    = "kv.has"
+	MethodKeys   = "kv.keys"
+	MethodSize   = "kv.size"
+	MethodClear  = "kv.clear"
+)
+
+// Error codes, as defined by JSON-RPC 2.0, and by the Server.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	// CodeKVError means the KV returned an error, such as key not found.
+	CodeKVError = -32000
+	// CodeUnauthorized means the token is missing or invalid.
+	CodeUnauthorized = -32001
+)
+
+// Request is a JSON-RPC request.
+// A request without ID is a notification, which gets no response.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// Response is a JSON-RPC response.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc: %s (%d)", e.Message, e.Code)
+}
+
+// AuthParams is the params of auth.
+type AuthParams struct {
+	Token string `json:"token"`
+}
+
+// KeyParams is the params of kv.get, kv.delete and kv.has.
+type KeyParams struct {
+	Key string `json:"key"`
+}
+
+// SetParams is the params of kv.set.
+type SetParams struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+	// MaxAge is the max age in milliseconds, nil keeps the origin expiry.
+	MaxAge *int64 `json:"maxAge,omitempty"`
+}
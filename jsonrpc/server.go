@@ -0,0 +1,271 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-zoox/kv/typing"
+)
+
+// Server exposes a KV over JSON-RPC 2.0, on HTTP and raw TCP.
+type Server struct {
+	KV     typing.KV
+	Config *ServerConfig
+}
+
+// ServerConfig is the configuration for Server.
+type ServerConfig struct {
+	// Token is the auth token, empty disables auth.
+	// HTTP clients send it as Authorization: Bearer <token>,
+	// TCP clients call auth once per connection.
+	Token string
+}
+
+// NewServer returns a new Server.
+func NewServer(kv typing.KV, cfg ...*ServerConfig) *Server {
+	cfgX := &ServerConfig{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		cfgX = cfg[0]
+	}
+
+	return &Server{
+		KV:     kv,
+		Config: cfgX,
+	}
+}
+
+func (s *Server) checkToken(token string) bool {
+	if s.Config.Token == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.Config.Token)) == 1
+}
+
+// ServeHTTP serves JSON-RPC requests over HTTP POST.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authorized := s.checkToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		writeJSON(w, errorResponse(nil, CodeParseError, err.Error()))
+		return
+	}
+
+	res := s.handle(raw, &authorized)
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeJSON(w, res)
+}
+
+// Serve accepts TCP connections on l, and serves each one in a goroutine.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves JSON-RPC requests on a TCP connection, one JSON value per request,
+// until the client closes the connection.
+func (s *Server) ServeConn(conn net.Conn) {
+	defer conn.Close()
+
+	authorized := s.checkToken("")
+	decoder := json.NewDecoder(bufio.NewReader(conn))
+	encoder := json.NewEncoder(conn)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				encoder.Encode(errorResponse(nil, CodeParseError, err.Error()))
+			}
+			return
+		}
+
+		if res := s.handle(raw, &authorized); res != nil {
+			if err := encoder.Encode(res); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle handles a single or batch request, it returns nil if there is nothing to respond.
+func (s *Server) handle(raw json.RawMessage, authorized *bool) any {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '[' {
+		res := s.handleOne(raw, authorized)
+		if res == nil {
+			return nil
+		}
+		return res
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(raw, &batch); err != nil {
+		return errorResponse(nil, CodeParseError, err.Error())
+	}
+	if len(batch) == 0 {
+		return errorResponse(nil, CodeInvalidRequest, "empty batch")
+	}
+
+	responses := []*Response{}
+	for _, one := range batch {
+		if res := s.handleOne(one, authorized); res != nil {
+			responses = append(responses, res)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+
+	return responses
+}
+
+func (s *Server) handleOne(raw json.RawMessage, authorized *bool) *Response {
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != Version || req.Method == "" {
+		return errorResponse(nil, CodeInvalidRequest, "invalid request")
+	}
+
+	result, rpcErr := s.call(&req, authorized)
+	if req.ID == nil {
+		return nil
+	}
+
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr.Code, rpcErr.Message)
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, CodeInternalError, err.Error())
+	}
+
+	return &Response{
+		JSONRPC: Version,
+		Result:  raw,
+		ID:      req.ID,
+	}
+}
+
+func (s *Server) call(req *Request, authorized *bool) (any, *Error) {
+	if req.Method == MethodAuth {
+		var params AuthParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &Error{CodeInvalidParams, err.Error()}
+		}
+
+		if !s.checkToken(params.Token) {
+			return nil, &Error{CodeUnauthorized, "invalid token"}
+		}
+
+		*authorized = true
+		return true, nil
+	}
+
+	if !*authorized {
+		return nil, &Error{CodeUnauthorized, "unauthorized"}
+	}
+
+	switch req.Method {
+	case MethodSet:
+		var params SetParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Key == "" || params.Value == nil {
+			return nil, &Error{CodeInvalidParams, "key and value are required"}
+		}
+
+		var err error
+		if params.MaxAge != nil {
+			err = s.KV.Set(params.Key, &params.Value, time.Duration(*params.MaxAge)*time.Millisecond)
+		} else {
+			err = s.KV.Set(params.Key, &params.Value)
+		}
+		if err != nil {
+			return nil, &Error{CodeKVError, err.Error()}
+		}
+		return true, nil
+
+	case MethodGet, MethodDelete, MethodHas:
+		var params KeyParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Key == "" {
+			return nil, &Error{CodeInvalidParams, "key is required"}
+		}
+
+		switch req.Method {
+		case MethodGet:
+			if !s.KV.Has(params.Key) {
+				return nil, &Error{CodeKVError, "key " + params.Key + " not found"}
+			}
+
+			var value json.RawMessage
+			if err := s.KV.Get(params.Key, &value); err != nil {
+				return nil, &Error{CodeKVError, err.Error()}
+			}
+			return value, nil
+		case MethodDelete:
+			if err := s.KV.Delete(params.Key); err != nil {
+				return nil, &Error{CodeKVError, err.Error()}
+			}
+			return true, nil
+		default:
+			return s.KV.Has(params.Key), nil
+		}
+
+	case MethodKeys:
+		return s.KV.Keys(), nil
+
+	case MethodSize:
+		return s.KV.Size(), nil
+
+	case MethodClear:
+		if err := s.KV.Clear(); err != nil {
+			return nil, &Error{CodeKVError, err.Error()}
+		}
+		return true, nil
+
+	default:
+		return nil, &Error{CodeMethodNotFound, "method not found: " + req.Method}
+	}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	return &Response{
+		JSONRPC: Version,
+		Error:   &Error{code, message},
+		ID:      id,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
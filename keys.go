@@ -0,0 +1,65 @@
+package kv
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+	"github.com/go-zoox/kv/typing"
+)
+
+// KeysWithPrefix returns the keys of store starting with the prefix,
+// natively if store implements typing.Prefix, otherwise filtered from Keys.
+func KeysWithPrefix(store typing.KV, prefix string) []string {
+	if p, ok := store.(typing.Prefix); ok {
+		return p.KeysWithPrefix(prefix)
+	}
+
+	keys := []string{}
+	for _, key := range store.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// KeysMatching returns the keys of store matching the redis glob pattern,
+// natively if store implements typing.Glob, otherwise filtered from Keys.
+func KeysMatching(store typing.KV, pattern string) []string {
+	if g, ok := store.(typing.Glob); ok {
+		return g.KeysMatching(pattern)
+	}
+
+	keys := []string{}
+	for _, key := range KeysWithPrefix(store, glob.Prefix(pattern)) {
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// DeletePrefix deletes the keys of store starting with the prefix,
+// natively if store implements typing.Prefix, otherwise one at a time.
+func DeletePrefix(store typing.KV, prefix string) error {
+	if p, ok := store.(typing.Prefix); ok {
+		return p.DeletePrefix(prefix)
+	}
+
+	for _, key := range KeysWithPrefix(store, prefix) {
+		if err := store.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Replace sets the value for the key of store, like Set, but drops the origin expiry if maxAge is not given,
+// atomically if store implements typing.Replacer, otherwise by Delete then Set.
+func Replace(store typing.KV, key string, value any, maxAge ...time.Duration) error {
+	return typing.Replace(store, key, value, maxAge...)
+}
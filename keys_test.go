@@ -0,0 +1,42 @@
+package kv
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/typing"
+)
+
+func TestKeysQueries(t *testing.T) {
+	for name, store := range map[string]typing.KV{
+		"native":    memory.New(),
+		"keys only": &keysOnly{memory.New()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"user:1", "user:22", "session:1"} {
+				value := key
+				store.Set(key, &value)
+			}
+
+			sorted := func(keys []string) string {
+				sort.Strings(keys)
+				return strings.Join(keys, ",")
+			}
+
+			if keys := sorted(KeysWithPrefix(store, "user:")); keys != "user:1,user:22" {
+				t.Errorf("Expected user keys, got %s", keys)
+			}
+			if keys := sorted(KeysMatching(store, "*:?")); keys != "session:1,user:1" {
+				t.Errorf("Expected keys matching *:?, got %s", keys)
+			}
+			if err := DeletePrefix(store, "user:"); err != nil {
+				t.Fatal(err)
+			}
+			if keys := sorted(store.Keys()); keys != "session:1" {
+				t.Errorf("Expected only session:1 left, got %s", keys)
+			}
+		})
+	}
+}
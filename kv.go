@@ -1,9 +1,18 @@
 package kv
 
 import (
+	"github.com/go-zoox/kv/bitcask"
+	"github.com/go-zoox/kv/bolt"
+	"github.com/go-zoox/kv/dynamodb"
 	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/http"
+	"github.com/go-zoox/kv/jsonrpc"
+	"github.com/go-zoox/kv/memcached"
 	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/mongodb"
 	"github.com/go-zoox/kv/redis"
+	"github.com/go-zoox/kv/sql"
+	"github.com/go-zoox/kv/sqlite"
 
 	"github.com/go-zoox/kv/typing"
 )
@@ -14,33 +23,40 @@ type KV = typing.KV
 // Config is the interface for KV Config.
 type Config = typing.Config
 
-// New returns a new KV.
-func New(cfg *typing.Config) (KV, error) {
-	switch cfg.Engine {
-	case "memory":
-		return NewMemory(), nil
-
-	case "filesystem":
-		if cfg.Config == nil {
-			return NewFileSystem()
-		}
-
-		return NewFileSystem(cfg.Config.(*fs.FileSystemOptions))
-
-	case "redis":
-		if cfg.Config == nil {
-			return nil, NewError(ErrConfigNotSet, "redis")
-		}
+func init() {
+	register("memory", true, func(cfg *memory.Config) (KV, error) {
+		return NewMemory(cfg), nil
+	})
+	register("filesystem", true, func(cfg *fs.FileSystemOptions) (KV, error) {
+		return NewFileSystem(cfg)
+	})
+	register("redis", false, NewRedis)
+	register("bitcask", true, func(cfg *bitcask.Config) (KV, error) {
+		return NewBitcask(cfg)
+	})
+	register("sqlite", false, NewSQLite)
+	register("bolt", false, NewBolt)
+	register("sql", false, NewSQL)
+	register("mongodb", false, NewMongoDB)
+	register("dynamodb", false, NewDynamoDB)
+	register("jsonrpc", false, NewJSONRPC)
+	register("http", false, NewHTTP)
+	register("memcached", false, NewMemcached)
+}
 
-		return NewRedis(cfg.Config.(*redis.Config))
-	default:
+// New returns a new KV, created by the factory registered for cfg.Engine.
+func New(cfg *typing.Config) (KV, error) {
+	factory, ok := lookup(cfg.Engine)
+	if !ok {
 		return nil, NewError(ErrUnknownEngine, cfg.Engine)
 	}
+
+	return factory(cfg.Config)
 }
 
 // NewMemory returns a new Memory KV.
-func NewMemory() KV {
-	return memory.New()
+func NewMemory(cfg ...*memory.Config) KV {
+	return memory.New(cfg...)
 }
 
 // NewFileSystem returns a new FileSystem KV.
@@ -52,3 +68,48 @@ func NewFileSystem(cfg ...*fs.FileSystemOptions) (KV, error) {
 func NewRedis(cfg *redis.Config) (KV, error) {
 	return redis.New(cfg)
 }
+
+// NewBitcask returns a new Bitcask KV.
+func NewBitcask(cfg ...*bitcask.Config) (KV, error) {
+	return bitcask.New(cfg...)
+}
+
+// NewSQLite returns a new SQLite KV.
+func NewSQLite(cfg *sqlite.SQLiteConfig) (KV, error) {
+	return sqlite.New(cfg)
+}
+
+// NewBolt returns a new Bolt KV.
+func NewBolt(cfg *bolt.Config) (KV, error) {
+	return bolt.New(cfg)
+}
+
+// NewSQL returns a new SQL KV, for PostgreSQL, MySQL or SQLite.
+func NewSQL(cfg *sql.Config) (KV, error) {
+	return sql.New(cfg)
+}
+
+// NewMongoDB returns a new MongoDB KV.
+func NewMongoDB(cfg *mongodb.Config) (KV, error) {
+	return mongodb.New(cfg)
+}
+
+// NewDynamoDB returns a new DynamoDB KV.
+func NewDynamoDB(cfg *dynamodb.Config) (KV, error) {
+	return dynamodb.New(cfg)
+}
+
+// NewJSONRPC returns a new JSONRPC KV, which calls a remote jsonrpc.Server.
+func NewJSONRPC(cfg *jsonrpc.Config) (KV, error) {
+	return jsonrpc.New(cfg)
+}
+
+// NewHTTP returns a new HTTP KV, which calls a remote REST server, see server/http.
+func NewHTTP(cfg *http.Config) (KV, error) {
+	return http.New(cfg)
+}
+
+// NewMemcached returns a new Memcached KV, over the memcached text protocol.
+func NewMemcached(cfg *memcached.Config) (KV, error) {
+	return memcached.New(cfg)
+}
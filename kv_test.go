@@ -1,18 +1,15 @@
 package kv
 
 import (
+	"path/filepath"
 	"testing"
 
+	"github.com/go-zoox/kv/sqlite"
 	"github.com/go-zoox/kv/typing"
 )
 
 func TestKV(t *testing.T) {
 	client, err := New(&typing.Config{
-		// Engine: "sqlite",
-		// Config: &sqlite.SQLiteConfig{
-		// 	Path:   "/tmp/test.db",
-		// 	Prefix: "go-zoox-test:",
-		// },
 		Engine: "memory",
 	})
 	if err != nil {
@@ -36,3 +33,29 @@ func TestKV(t *testing.T) {
 		t.Errorf("Expected size 1, got %d", client.Size())
 	}
 }
+
+func TestSQLite(t *testing.T) {
+	client, err := New(&typing.Config{
+		Engine: "sqlite",
+		Config: &sqlite.SQLiteConfig{
+			Path:   filepath.Join(t.TempDir(), "test.db"),
+			Prefix: "go-zoox-test:",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Clear()
+	defer client.Clear()
+
+	valueBefore := "value"
+	client.Set("key", &valueBefore)
+	var value string
+	if err := client.Get("key", &value); err != nil || value != "value" {
+		t.Error("Expected value to be 'value'")
+	}
+
+	if client.Size() != 1 {
+		t.Errorf("Expected size 1, got %d", client.Size())
+	}
+}
@@ -0,0 +1,159 @@
+package loader
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/typing"
+)
+
+// Loader wraps a KV with GetOrLoad, which loads the missing values once per key,
+// however many goroutines ask for them at the same time.
+type Loader struct {
+	typing.KV
+	Config *Config
+
+	mu    sync.Mutex
+	calls map[string]*call
+
+	// negatives caches the errors of the loaders, locally.
+	negatives *memory.Memory
+}
+
+// Config is the configuration for Loader.
+type Config struct {
+	// NegativeTTL is how long an error of a loader is cached and returned
+	// without calling the loader again, 0 to disable.
+	NegativeTTL time.Duration
+
+	// NegativeMaxSize is the max number of cached errors, default is 10000.
+	NegativeMaxSize int
+}
+
+// call is a load in flight, shared by the callers of the same key.
+type call struct {
+	wg  sync.WaitGroup
+	raw []byte
+	err error
+}
+
+// negative is a cached error.
+type negative struct {
+	err error
+}
+
+// New returns a new Loader on kv.
+func New(kv typing.KV, cfg ...*Config) *Loader {
+	cfgX := &Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		cfgX = cfg[0]
+	}
+
+	if cfgX.NegativeMaxSize <= 0 {
+		cfgX.NegativeMaxSize = 10000
+	}
+
+	return &Loader{
+		KV:        kv,
+		Config:    cfgX,
+		calls:     make(map[string]*call),
+		negatives: memory.New(&memory.Config{MaxSize: cfgX.NegativeMaxSize}),
+	}
+}
+
+// GetOrLoad gets the value of the key into dest, or on miss, calls loader,
+// sets its value with maxAge ttl, and decodes it into dest.
+// A ttl of 0 means the loaded value never expires.
+//
+// The concurrent calls for the same key share a single call of loader,
+// and if NegativeTTL is set, its error is returned without calling loader again until NegativeTTL.
+func (l *Loader) GetOrLoad(key string, dest any, ttl time.Duration, loader func() (any, error)) error {
+	if l.KV.Has(key) {
+		if err := l.KV.Get(key, dest); err == nil {
+			return nil
+		}
+	}
+
+	var n negative
+	if l.negatives.Has(key) && l.negatives.Get(key, &n) == nil {
+		return n.err
+	}
+
+	raw, err := l.do(key, func() ([]byte, error) {
+		return l.load(key, ttl, loader)
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, dest)
+}
+
+// Forget removes the cached error of the key, if any.
+func (l *Loader) Forget(key string) {
+	l.negatives.Delete(key)
+}
+
+// do calls fn once for the concurrent calls with the same key.
+func (l *Loader) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	l.mu.Lock()
+	if c, ok := l.calls[key]; ok {
+		l.mu.Unlock()
+		c.wg.Wait()
+		return c.raw, c.err
+	}
+
+	c := &call{}
+	c.wg.Add(1)
+	l.calls[key] = c
+	l.mu.Unlock()
+
+	// release the waiters even if fn panics
+	defer func() {
+		l.mu.Lock()
+		delete(l.calls, key)
+		l.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.raw, c.err = fn()
+	return c.raw, c.err
+}
+
+func (l *Loader) load(key string, ttl time.Duration, loader func() (any, error)) ([]byte, error) {
+	value, err := loader()
+	if err != nil {
+		if l.Config.NegativeTTL > 0 {
+			l.negatives.Set(key, &negative{err}, l.Config.NegativeTTL)
+		}
+		return nil, err
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// the engines, such as memory, expect a pointer
+	v := reflect.ValueOf(value)
+	if v.IsValid() && v.Kind() != reflect.Ptr {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		value = p.Interface()
+	}
+
+	if ttl > 0 {
+		err = l.KV.Set(key, value, ttl)
+	} else {
+		err = l.KV.Set(key, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l.negatives.Delete(key)
+	return raw, nil
+}
@@ -0,0 +1,107 @@
+package loader
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/test"
+)
+
+type user struct {
+	Name string
+}
+
+func TestKV(t *testing.T) {
+	test.RunTestCases(t, New(memory.New()))
+}
+
+func TestGetOrLoad(t *testing.T) {
+	l := New(memory.New())
+
+	var calls int32
+	loader := func() (any, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return user{Name: "zero"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var u user
+			if err := l.GetOrLoad("user:1", &u, time.Minute, loader); err != nil || u.Name != "zero" {
+				t.Errorf("Expected loaded user, got %+v, %v", u, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected 1 load, got %d", calls)
+	}
+
+	var u user
+	if err := l.GetOrLoad("user:1", &u, time.Minute, loader); err != nil || u.Name != "zero" || calls != 1 {
+		t.Errorf("Expected cached user without load, got %+v, %v, %d loads", u, err, calls)
+	}
+}
+
+func TestNegativeCaching(t *testing.T) {
+	l := New(memory.New(), &Config{NegativeTTL: 50 * time.Millisecond})
+
+	calls := 0
+	errNotFound := errors.New("not found")
+	loader := func() (any, error) {
+		calls++
+		if calls == 1 {
+			return nil, errNotFound
+		}
+		return "value", nil
+	}
+
+	var value string
+	for i := 0; i < 3; i++ {
+		if err := l.GetOrLoad("key", &value, 0, loader); err != errNotFound {
+			t.Errorf("Expected cached error, got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 load, got %d", calls)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if err := l.GetOrLoad("key", &value, 0, loader); err != nil || value != "value" {
+		t.Errorf("Expected value after NegativeTTL, got %q, %v", value, err)
+	}
+}
+
+func TestForget(t *testing.T) {
+	l := New(memory.New(), &Config{NegativeTTL: time.Minute})
+
+	fail := true
+	loader := func() (any, error) {
+		if fail {
+			return nil, errors.New("failed")
+		}
+		return 1, nil
+	}
+
+	var n int
+	l.GetOrLoad("key", &n, 0, loader)
+	fail = false
+	if err := l.GetOrLoad("key", &n, 0, loader); err == nil {
+		t.Error("Expected cached error")
+	}
+
+	l.Forget("key")
+	if err := l.GetOrLoad("key", &n, 0, loader); err != nil || n != 1 {
+		t.Errorf("Expected 1 after forget, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,429 @@
+package memcached
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+	"github.com/go-zoox/kv/typing"
+)
+
+// maxKeyLength is the max length of a key in memcached, with the prefix.
+const maxKeyLength = 250
+
+// maxRelativeExptime is the max exptime in seconds sent as relative,
+// longer max ages are sent as unix timestamps, like memcached requires.
+const maxRelativeExptime = 60 * 60 * 24 * 30
+
+// Memcached is a Key-Value Store in memcached, over the text protocol.
+type Memcached struct {
+	sync.Mutex
+	Config *Config
+
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Config is the configuration for Memcached.
+type Config struct {
+	// Addr is the address of the memcached server, such as localhost:11211
+	Addr string
+
+	// Prefix is the prefix to use for all keys
+	Prefix string
+
+	// Timeout is the timeout of each command, default is 10 seconds.
+	Timeout time.Duration
+}
+
+// New returns a new Memcached.
+func New(cfg *Config) (*Memcached, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("memcached addr is required")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &Memcached{
+		Config: cfg,
+	}, nil
+}
+
+// Close closes the connection.
+func (m *Memcached) Close() error {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.closeConn()
+}
+
+func (m *Memcached) closeConn() error {
+	if m.conn == nil {
+		return nil
+	}
+
+	err := m.conn.Close()
+	m.conn = nil
+	m.r = nil
+	return err
+}
+
+// getKey returns the key with the prefix, or an error if memcached would not accept it,
+// as the key is sent as is in the command line.
+func (m *Memcached) getKey(key string) (string, error) {
+	key = m.Config.Prefix + key
+	if len(key) > maxKeyLength {
+		return "", fmt.Errorf("memcached: key %q is longer than %d bytes", key, maxKeyLength)
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return "", fmt.Errorf("memcached: key %q contains whitespace or control characters", key)
+		}
+	}
+
+	return key, nil
+}
+
+// exptime converts maxAge into a memcached exptime, rounded up to seconds.
+func exptime(maxAge time.Duration) int64 {
+	if maxAge <= 0 {
+		return -1
+	}
+
+	seconds := int64((maxAge + time.Second - 1) / time.Second)
+	if seconds > maxRelativeExptime {
+		return time.Now().Unix() + seconds
+	}
+
+	return seconds
+}
+
+// do sends a command, and reads the reply lines until one of the terminal lines.
+// The connection is dropped on any i/o error, and dialed again on the next command.
+func (m *Memcached) do(cmd string, data []byte, read func(r *bufio.Reader) error) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.conn == nil {
+		conn, err := net.DialTimeout("tcp", m.Config.Addr, m.Config.Timeout)
+		if err != nil {
+			return err
+		}
+
+		m.conn = conn
+		m.r = bufio.NewReader(conn)
+	}
+
+	m.conn.SetDeadline(time.Now().Add(m.Config.Timeout))
+
+	buf := []byte(cmd + "\r\n")
+	if data != nil {
+		buf = append(append(buf, data...), '\r', '\n')
+	}
+	if _, err := m.conn.Write(buf); err != nil {
+		m.closeConn()
+		return err
+	}
+
+	if err := read(m.r); err != nil {
+		if _, ok := err.(*replyError); !ok {
+			m.closeConn()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// replyError is an error reply from the server, the connection stays usable.
+type replyError struct {
+	reply string
+}
+
+func (e *replyError) Error() string {
+	return "memcached: " + e.reply
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// expect reads a single reply line, which must be one of ok.
+func expect(ok ...string) func(r *bufio.Reader) error {
+	return func(r *bufio.Reader) error {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+
+		for _, o := range ok {
+			if line == o {
+				return nil
+			}
+		}
+
+		return &replyError{line}
+	}
+}
+
+// get returns the value of the key, or nil if it does not exist.
+func (m *Memcached) get(key string) ([]byte, error) {
+	key, err := m.getKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	err = m.do("get "+key, nil, func(r *bufio.Reader) error {
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return err
+			}
+
+			if line == "END" {
+				return nil
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) < 4 || fields[0] != "VALUE" {
+				return &replyError{line}
+			}
+
+			size, err := strconv.Atoi(fields[3])
+			if err != nil || size < 0 {
+				return &replyError{line}
+			}
+
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return err
+			}
+			value = data[:size]
+		}
+	})
+
+	return value, err
+}
+
+// Set sets the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge, rounded up to seconds.
+// Otherwise the origin expiry is kept, read by mg before the set, which requires memcached 1.6.
+func (m *Memcached) Set(key string, value any, maxAge ...time.Duration) error {
+	exp := int64(0)
+	if len(maxAge) > 0 {
+		exp = exptime(maxAge[0])
+	} else {
+		// set without exptime would clear the origin expiry
+		ttl, err := m.TTL(key)
+		if err != nil && !errors.Is(err, typing.ErrNotFound) {
+			return err
+		}
+		if ttl > 0 {
+			exp = exptime(ttl)
+		}
+	}
+
+	return m.set(key, value, exp)
+}
+
+// Replace sets the value for the given key, which never expires.
+func (m *Memcached) Replace(key string, value any) error {
+	return m.set(key, value, 0)
+}
+
+func (m *Memcached) set(key string, value any, exp int64) error {
+	key, err := m.getKey(key)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("set %s 0 %d %d", key, exp, len(raw))
+	return m.do(cmd, raw, expect("STORED"))
+}
+
+// TTL returns the remaining time to live of the given key, in seconds, or -1 if it never expires.
+// It is read by mg, which requires memcached 1.6.
+func (m *Memcached) TTL(key string) (time.Duration, error) {
+	keyX, err := m.getKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	ttl := time.Duration(0)
+	found := false
+	err = m.do("mg "+keyX+" t", nil, func(r *bufio.Reader) error {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+
+		if line == "EN" {
+			return nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "HD" || !strings.HasPrefix(fields[1], "t") {
+			return &replyError{line}
+		}
+
+		seconds, err := strconv.ParseInt(fields[1][1:], 10, 64)
+		if err != nil {
+			return &replyError{line}
+		}
+
+		found = true
+		ttl = -1
+		if seconds >= 0 {
+			ttl = time.Duration(seconds) * time.Second
+		}
+		return nil
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("key %s %w", key, typing.ErrNotFound)
+	}
+
+	return ttl, err
+}
+
+// Get returns the value for the given key.
+func (m *Memcached) Get(key string, value any) error {
+	raw, err := m.get(key)
+	if err != nil {
+		return err
+	}
+
+	if raw == nil {
+		return fmt.Errorf("key %s not found", key)
+	}
+
+	return json.Unmarshal(raw, value)
+}
+
+// Delete deletes the value for the given key.
+func (m *Memcached) Delete(key string) error {
+	key, err := m.getKey(key)
+	if err != nil {
+		return err
+	}
+
+	return m.do("delete "+key, nil, expect("DELETED", "NOT_FOUND"))
+}
+
+// Has returns true if the given key exists in the kv.
+func (m *Memcached) Has(key string) bool {
+	raw, err := m.get(key)
+	return err == nil && raw != nil
+}
+
+// Keys returns the keys of the kv, listed by lru_crawler metadump.
+func (m *Memcached) Keys() []string {
+	keys := []string{}
+	err := m.do("lru_crawler metadump all", nil, func(r *bufio.Reader) error {
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return err
+			}
+
+			if line == "END" {
+				return nil
+			}
+			if !strings.HasPrefix(line, "key=") {
+				return &replyError{line}
+			}
+
+			fields := strings.Fields(line)
+			key, err := url.QueryUnescape(strings.TrimPrefix(fields[0], "key="))
+			if err != nil || !strings.HasPrefix(key, m.Config.Prefix) {
+				continue
+			}
+
+			keys = append(keys, key[len(m.Config.Prefix):])
+		}
+	})
+	if err != nil {
+		return []string{}
+	}
+
+	return keys
+}
+
+// KeysWithPrefix returns the keys starting with the given prefix, filtered from Keys.
+func (m *Memcached) KeysWithPrefix(prefix string) []string {
+	return m.keys(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// KeysMatching returns the keys matching the glob pattern, filtered from Keys.
+func (m *Memcached) KeysMatching(pattern string) []string {
+	return m.keys(func(key string) bool { return glob.Match(pattern, key) })
+}
+
+func (m *Memcached) keys(match func(key string) bool) []string {
+	keys := []string{}
+	for _, key := range m.Keys() {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// DeletePrefix deletes the keys starting with the given prefix.
+func (m *Memcached) DeletePrefix(prefix string) error {
+	for _, key := range m.KeysWithPrefix(prefix) {
+		if err := m.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Size returns the number of elements in the kv.
+func (m *Memcached) Size() int {
+	return len(m.Keys())
+}
+
+// Clear removes all elements from the kv.
+// Without a prefix it flushes the server, otherwise it deletes the keys with the prefix.
+func (m *Memcached) Clear() error {
+	if m.Config.Prefix == "" {
+		return m.do("flush_all", nil, expect("OK"))
+	}
+
+	return m.DeletePrefix("")
+}
+
+// ForEach calls the given function for each key-value pair in the kv.
+func (m *Memcached) ForEach(f func(string, interface{})) {
+	for _, key := range m.Keys() {
+		var value any
+		if err := m.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package memcached
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+	server "github.com/go-zoox/kv/server/memcached"
+	"github.com/go-zoox/kv/test"
+	"github.com/go-zoox/kv/typing"
+)
+
+func createClient(t *testing.T, prefix string) *Memcached {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go server.New(memory.New()).Serve(l)
+
+	client, err := New(&Config{Addr: l.Addr().String(), Prefix: prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestKV(t *testing.T) {
+	test.RunTestCases(t, createClient(t, ""))
+}
+
+func TestPrefix(t *testing.T) {
+	test.RunTestCases(t, createClient(t, "go-zoox-test:"))
+}
+
+func TestInvalidKey(t *testing.T) {
+	client := createClient(t, "go-zoox-test:")
+
+	for _, key := range []string{"with space", "with\nnewline", "with\x00null", strings.Repeat("k", 240)} {
+		if err := client.Set(key, "value"); err == nil {
+			t.Errorf("Expected key %q to be an error on Set", key)
+		}
+		if err := client.Delete(key); err == nil {
+			t.Errorf("Expected key %q to be an error on Delete", key)
+		}
+		if client.Has(key) {
+			t.Errorf("Expected key %q not to exist", key)
+		}
+	}
+
+	if err := client.Set(strings.Repeat("k", 237), "value"); err != nil {
+		t.Errorf("Expected key of 250 bytes with the prefix, got %v", err)
+	}
+}
+
+func TestNegativeSize(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			if _, err := r.ReadString('\n'); err != nil {
+				return
+			}
+			conn.Write([]byte("VALUE key 0 -5\r\n"))
+		}
+	}()
+
+	client, _ := New(&Config{Addr: l.Addr().String()})
+	defer client.Close()
+
+	var value string
+	if err := client.Get("key", &value); err == nil {
+		t.Error("Expected negative size to be an error")
+	}
+}
+
+func TestSetKeepsTTL(t *testing.T) {
+	client := createClient(t, "")
+
+	if err := client.Set("key", "value", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Set("key", "value2"); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := client.TTL("key"); err != nil || d <= 0 || d > time.Minute {
+		t.Errorf("Expected the expiry kept by Set, got %s, %v", d, err)
+	}
+
+	if err := client.Replace("key", "value3"); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := client.TTL("key"); err != nil || d >= 0 {
+		t.Errorf("Expected the expiry dropped by Replace, got %s, %v", d, err)
+	}
+
+	if _, err := client.TTL("missing"); !errors.Is(err, typing.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package memory
+
+// expiry is the expiry of a key, in expiries.
+type expiry struct {
+	key       string
+	expiresAt int64
+	// index is the index in expiries, maintained by the heap.
+	index int
+}
+
+// expiries is a min-heap of container/heap of the keys with an expiry, the soonest first,
+// so that evict purges the expired entries without scanning all of them.
+type expiries []*expiry
+
+func (h expiries) Len() int { return len(h) }
+
+func (h expiries) Less(i, j int) bool { return h[i].expiresAt < h[j].expiresAt }
+
+func (h expiries) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiries) Push(x any) {
+	e := x.(*expiry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *expiries) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
@@ -1,16 +1,38 @@
 package memory
 
 import (
+	"container/heap"
+	"container/list"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/go-zoox/kv/glob"
+	"github.com/go-zoox/kv/typing"
 )
 
 // Memory is a Key-Value Store in Memory, like JavaScript Map for Go.
 type Memory struct {
 	sync.RWMutex
+	Config *Config
+
 	data map[string]Value
+	// order is the keys in the order of writes, the least recently written first.
+	order    *list.List
+	elements map[string]*list.Element
+	// expiries tracks the keys with an expiry, if MaxSize is set.
+	expiries expiries
+	expiryOf map[string]*expiry
+}
+
+// Config is the configuration for Memory.
+type Config struct {
+	// MaxSize is the max number of entries, 0 for unlimited.
+	// When full, the expired entries are purged,
+	// and then the least recently written entries are evicted.
+	MaxSize int
 }
 
 // Value is a value of Memory.
@@ -20,9 +42,18 @@ type Value struct {
 }
 
 // New returns a new MemoryKV.
-func New() *Memory {
+func New(cfg ...*Config) *Memory {
+	config := &Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		config = cfg[0]
+	}
+
 	return &Memory{
-		data: make(map[string]Value),
+		Config:   config,
+		data:     make(map[string]Value),
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+		expiryOf: make(map[string]*expiry),
 	}
 }
 
@@ -47,10 +78,85 @@ func (m *Memory) Set(key string, value interface{}, maxAge ...time.Duration) err
 		expiresAt = val.ExpiresAt
 	}
 
-	m.data[key] = Value{value, expiresAt}
+	m.set(key, value, expiresAt)
+	return nil
+}
+
+// Replace sets the value for the given key, which never expires.
+func (m *Memory) Replace(key string, value any) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if value == nil {
+		return fmt.Errorf("value is nil")
+	}
+
+	m.set(key, value, 0)
 	return nil
 }
 
+func (m *Memory) set(key string, value any, expiresAt int64) {
+	if _, ok := m.data[key]; !ok && m.Config.MaxSize > 0 && len(m.data) >= m.Config.MaxSize {
+		m.evict()
+	}
+
+	m.data[key] = Value{value, expiresAt}
+	if el, ok := m.elements[key]; ok {
+		m.order.MoveToBack(el)
+	} else {
+		m.elements[key] = m.order.PushBack(key)
+	}
+
+	if m.Config.MaxSize > 0 {
+		m.track(key, expiresAt)
+	}
+}
+
+// track updates the expiry of the key in expiries, where the keys without expiry are not.
+func (m *Memory) track(key string, expiresAt int64) {
+	e, ok := m.expiryOf[key]
+	switch {
+	case ok && expiresAt == 0:
+		m.untrack(key)
+	case ok:
+		e.expiresAt = expiresAt
+		heap.Fix(&m.expiries, e.index)
+	case expiresAt > 0:
+		e = &expiry{key: key, expiresAt: expiresAt}
+		heap.Push(&m.expiries, e)
+		m.expiryOf[key] = e
+	}
+}
+
+func (m *Memory) untrack(key string) {
+	if e, ok := m.expiryOf[key]; ok {
+		heap.Remove(&m.expiries, e.index)
+		delete(m.expiryOf, key)
+	}
+}
+
+// evict makes room for a new entry, by purging the expired entries, the soonest first,
+// and then evicting the least recently written ones.
+func (m *Memory) evict() {
+	now := now()
+	for len(m.expiries) > 0 && m.expiries[0].expiresAt < now {
+		m.remove(m.expiries[0].key)
+	}
+
+	for len(m.data) >= m.Config.MaxSize {
+		m.remove(m.order.Front().Value.(string))
+	}
+}
+
+func (m *Memory) remove(key string) {
+	delete(m.data, key)
+	if el, ok := m.elements[key]; ok {
+		m.order.Remove(el)
+		delete(m.elements, key)
+	}
+	m.untrack(key)
+}
+
 // Get returns the value for the given key.
 func (m *Memory) Get(key string, value interface{}) error {
 	m.RLock()
@@ -76,12 +182,29 @@ func (m *Memory) Get(key string, value interface{}) error {
 	return nil
 }
 
+// TTL returns the remaining time to live of the given key, or -1 if it never expires.
+func (m *Memory) TTL(key string) (time.Duration, error) {
+	m.RLock()
+	val, ok := m.data[key]
+	m.RUnlock()
+
+	if !ok || (val.ExpiresAt > 0 && val.ExpiresAt < now()) {
+		return 0, fmt.Errorf("key %s %w", key, typing.ErrNotFound)
+	}
+
+	if val.ExpiresAt == 0 {
+		return -1, nil
+	}
+
+	return time.Duration(val.ExpiresAt-now()) * time.Millisecond, nil
+}
+
 // Delete deletes the value for the given key.
 func (m *Memory) Delete(key string) error {
 	m.Lock()
 	defer m.Unlock()
 
-	delete(m.data, key)
+	m.remove(key)
 	return nil
 }
 
@@ -118,6 +241,50 @@ func (m *Memory) Keys() []string {
 	return keys
 }
 
+// KeysWithPrefix returns the keys starting with the given prefix.
+func (m *Memory) KeysWithPrefix(prefix string) []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	keys := []string{}
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// KeysMatching returns the keys matching the glob pattern.
+func (m *Memory) KeysMatching(pattern string) []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	keys := []string{}
+	for k := range m.data {
+		if glob.Match(pattern, k) {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// DeletePrefix deletes the keys starting with the given prefix.
+func (m *Memory) DeletePrefix(prefix string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) {
+			m.remove(k)
+		}
+	}
+
+	return nil
+}
+
 // Size returns the number of elements in the kv.
 func (m *Memory) Size() int {
 	m.RLock()
@@ -132,6 +299,10 @@ func (m *Memory) Clear() error {
 	defer m.Unlock()
 
 	m.data = make(map[string]Value)
+	m.order.Init()
+	m.elements = make(map[string]*list.Element)
+	m.expiries = nil
+	m.expiryOf = make(map[string]*expiry)
 	return nil
 }
 
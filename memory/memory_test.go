@@ -2,6 +2,7 @@ package memory
 
 import (
 	"testing"
+	"time"
 
 	"github.com/go-zoox/kv/test"
 )
@@ -22,3 +23,52 @@ func TestSetValueNil(t *testing.T) {
 		t.Errorf("Expected error, got nil")
 	}
 }
+
+func TestMaxSize(t *testing.T) {
+	client := New(&Config{MaxSize: 2})
+
+	a, b, c := "a", "b", "c"
+	client.Set("a", &a)
+	client.Set("b", &b)
+	client.Set("a", &a)
+	client.Set("c", &c)
+
+	if client.Size() != 2 {
+		t.Errorf("Expected size 2, got %d", client.Size())
+	}
+	if client.Has("b") {
+		t.Error("Expected the least recently written key b to be evicted")
+	}
+	if !client.Has("a") || !client.Has("c") {
+		t.Error("Expected keys a and c to be kept")
+	}
+
+	client.Set("b", &b, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	client.Set("d", &c)
+	if !client.Has("c") || !client.Has("d") {
+		t.Error("Expected the expired key to be purged before evicting")
+	}
+}
+
+func TestMaxSizeExpiries(t *testing.T) {
+	client := New(&Config{MaxSize: 3})
+
+	a, b, c := "a", "b", "c"
+	client.Set("a", &a, time.Millisecond)
+	client.Replace("a", &a)
+	client.Set("b", &b, time.Millisecond)
+	client.Set("b", &b, time.Hour)
+	client.Set("c", &c, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	client.Set("d", &c)
+	if client.Has("c") || !client.Has("a") || !client.Has("b") || !client.Has("d") {
+		t.Errorf("Expected only the expired key c to be purged, got %v", client.Keys())
+	}
+
+	client.Delete("b")
+	if len(client.expiries) != 0 || len(client.expiryOf) != 0 {
+		t.Errorf("Expected no tracked expiries, got %d", len(client.expiries))
+	}
+}
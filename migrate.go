@@ -0,0 +1,202 @@
+package kv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/typing"
+)
+
+// MigrateOptions are the options of Migrate.
+type MigrateOptions struct {
+	// Prefix only migrates the keys with the prefix.
+	Prefix string
+
+	// Concurrency is the number of keys migrated at the same time, default is 4.
+	Concurrency int
+
+	// DryRun counts the keys to migrate, without writing.
+	DryRun bool
+
+	// Checkpoint is the path of a file which records the migrated keys, one quoted key per line,
+	// so that an interrupted migration resumes where it stopped.
+	// It is removed once the migration succeeds.
+	Checkpoint string
+}
+
+// MigrateResult is the result of Migrate.
+type MigrateResult struct {
+	// Copied is the number of copied keys, or to copy in dry-run.
+	Copied int
+	// Resumed is the number of keys skipped, as recorded in the checkpoint.
+	Resumed int
+	// Expired is the number of keys expired before they were copied.
+	Expired int
+	// Failed is the number of keys which failed to copy.
+	Failed int
+}
+
+// Migrate copies the keys of src to dst, with their remaining time to live if src implements typing.TTL.
+// The values are copied as decoded from src, so dst gets the JSON of the same value.
+// It returns the errors of all failed keys, after trying all of them.
+func Migrate(src, dst typing.KV, opts ...*MigrateOptions) (*MigrateResult, error) {
+	opt := &MigrateOptions{}
+	if len(opts) > 0 && opts[0] != nil {
+		opt = opts[0]
+	}
+
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	done := map[string]bool{}
+	var checkpoint *os.File
+	if opt.Checkpoint != "" && !opt.DryRun {
+		var err error
+		if done, err = readCheckpoint(opt.Checkpoint); err != nil {
+			return nil, err
+		}
+
+		if checkpoint, err = os.OpenFile(opt.Checkpoint, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err != nil {
+			return nil, err
+		}
+		defer checkpoint.Close()
+	}
+
+	result := &MigrateResult{}
+	keys := make(chan string)
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for key := range keys {
+				copied, err := copyKey(src, dst, key, opt.DryRun)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					result.Failed++
+					errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				case !copied:
+					result.Expired++
+				default:
+					result.Copied++
+				}
+				if err == nil && checkpoint != nil {
+					if _, errX := fmt.Fprintln(checkpoint, strconv.Quote(key)); errX != nil {
+						errs = append(errs, fmt.Errorf("checkpoint: %w", errX))
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, key := range KeysWithPrefix(src, opt.Prefix) {
+		if done[key] {
+			result.Resumed++
+			continue
+		}
+
+		keys <- key
+	}
+	close(keys)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return result, errors.Join(errs...)
+	}
+
+	if checkpoint != nil {
+		checkpoint.Close()
+		if err := os.Remove(opt.Checkpoint); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// Copy copies the key of src to dst, with its remaining time to live if src implements typing.TTL,
+// and returns false if the key does not exist in src, such as expired since it was listed.
+// The value replaces the one in dst and its expiry, see Replace.
+func Copy(src, dst typing.KV, key string) (bool, error) {
+	return copyKey(src, dst, key, false)
+}
+
+func copyKey(src, dst typing.KV, key string, dryRun bool) (bool, error) {
+	var maxAge []time.Duration
+	if ttl, ok := src.(typing.TTL); ok {
+		d, err := ttl.TTL(key)
+		if errors.Is(err, typing.ErrNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if d > 0 {
+			maxAge = append(maxAge, d)
+		}
+	} else if !src.Has(key) {
+		return false, nil
+	}
+
+	var value any
+	if err := src.Get(key, &value); err != nil {
+		return false, err
+	}
+
+	if dryRun {
+		return true, nil
+	}
+
+	return true, Replace(dst, key, pointerTo(value), maxAge...)
+}
+
+// pointerTo returns a pointer of the decoded value, as the engines, such as memory, expect a pointer of the concrete value.
+func pointerTo(value any) any {
+	if value == nil {
+		return &value
+	}
+
+	v := reflect.New(reflect.TypeOf(value))
+	v.Elem().Set(reflect.ValueOf(value))
+	return v.Interface()
+}
+
+func readCheckpoint(path string) (map[string]bool, error) {
+	done := map[string]bool{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return done, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, err := strconv.Unquote(scanner.Text())
+		if err != nil {
+			// a torn last line, the key is migrated again
+			continue
+		}
+
+		done[key] = true
+	}
+
+	return done, scanner.Err()
+}
@@ -0,0 +1,164 @@
+package kv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/typing"
+)
+
+// failingKV fails to set the key fail.
+type failingKV struct {
+	typing.KV
+}
+
+func (f *failingKV) Set(key string, value any, maxAge ...time.Duration) error {
+	if key == "fail" {
+		return errors.New("failed")
+	}
+
+	return f.KV.Set(key, value, maxAge...)
+}
+
+// ttlFailingKV fails to report the ttl of the key fail, like on a network error.
+type ttlFailingKV struct {
+	*memory.Memory
+}
+
+func (f ttlFailingKV) TTL(key string) (time.Duration, error) {
+	if key == "fail" {
+		return 0, errors.New("timeout")
+	}
+
+	return f.Memory.TTL(key)
+}
+
+func TestMigrate(t *testing.T) {
+	src, _ := fs.New(&fs.FileSystemOptions{Dir: t.TempDir()})
+	dst := memory.New()
+
+	for i := 0; i < 10; i++ {
+		src.Set("user:"+strconv.Itoa(i), map[string]any{"id": i})
+	}
+	src.Set("session:1", "token", time.Hour)
+	src.Set("session:2", "expired", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	result, err := Migrate(src, dst, &MigrateOptions{Prefix: "user:", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 10 || dst.Size() != 0 {
+		t.Errorf("Expected 10 keys to copy without writes, got %+v, size %d", result, dst.Size())
+	}
+
+	result, err = Migrate(src, dst, &MigrateOptions{Concurrency: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 11 || result.Expired != 1 {
+		t.Errorf("Expected 11 copied and 1 expired, got %+v", result)
+	}
+
+	var token string
+	if err := dst.Get("session:1", &token); err != nil || token != "token" {
+		t.Errorf("Expected token, got %q, %v", token, err)
+	}
+	if d, err := dst.TTL("session:1"); err != nil || d <= 0 || d > time.Hour {
+		t.Errorf("Expected remaining ttl, got %s, %v", d, err)
+	}
+
+	var user map[string]any
+	if err := dst.Get("user:3", &user); err != nil || user["id"] != float64(3) {
+		t.Errorf("Expected user 3, got %v, %v", user, err)
+	}
+}
+
+func TestMigrateCheckpoint(t *testing.T) {
+	src := memory.New()
+	for _, key := range []string{"a", "b", "fail"} {
+		value := key
+		src.Set(key, &value)
+	}
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	dst := &failingKV{KV: memory.New()}
+	result, err := Migrate(src, dst, &MigrateOptions{Checkpoint: checkpoint})
+	if err == nil || result.Copied != 2 || result.Failed != 1 {
+		t.Fatalf("Expected 1 failure, got %+v, %v", result, err)
+	}
+
+	// resume, with the failing key fixed
+	dst.Delete("a")
+	result, err = Migrate(src, dst.KV, &MigrateOptions{Checkpoint: checkpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Resumed != 2 || result.Copied != 1 {
+		t.Errorf("Expected 2 resumed and 1 copied, got %+v", result)
+	}
+	if dst.Has("a") {
+		t.Error("Expected the checkpointed key not to be copied again")
+	}
+	if _, err := os.Stat(checkpoint); !os.IsNotExist(err) {
+		t.Error("Expected the checkpoint to be removed on success")
+	}
+}
+
+func TestMigrateTTLError(t *testing.T) {
+	src := memory.New()
+	for _, key := range []string{"a", "fail"} {
+		value := key
+		src.Set(key, &value)
+	}
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	dst := memory.New()
+	result, err := Migrate(ttlFailingKV{src}, dst, &MigrateOptions{Checkpoint: checkpoint})
+	if err == nil || result.Copied != 1 || result.Failed != 1 || result.Expired != 0 {
+		t.Fatalf("Expected the ttl error to fail the key, got %+v, %v", result, err)
+	}
+
+	// the failed key is not checkpointed, so it is copied on resume
+	result, err = Migrate(src, dst, &MigrateOptions{Checkpoint: checkpoint})
+	if err != nil || result.Resumed != 1 || result.Copied != 1 || !dst.Has("fail") {
+		t.Errorf("Expected the failed key copied on resume, got %+v, %v", result, err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src, _ := fs.New(&fs.FileSystemOptions{Dir: t.TempDir()})
+	dst := memory.New()
+
+	src.Set("null", nil)
+	src.Set("persistent", "value")
+	old := "old"
+	dst.Set("persistent", &old, time.Hour)
+
+	for _, key := range []string{"null", "persistent"} {
+		if copied, err := Copy(src, dst, key); err != nil || !copied {
+			t.Errorf("Expected %s copied, got %v, %v", key, copied, err)
+		}
+	}
+	if copied, err := Copy(src, dst, "missing"); err != nil || copied {
+		t.Errorf("Expected missing key not copied, got %v, %v", copied, err)
+	}
+
+	if !dst.Has("null") {
+		t.Error("Expected null value copied")
+	}
+
+	var value string
+	if err := dst.Get("persistent", &value); err != nil || value != "value" {
+		t.Errorf("Expected value, got %q, %v", value, err)
+	}
+	if d, err := dst.TTL("persistent"); err != nil || d >= 0 {
+		t.Errorf("Expected the expiry of dst dropped, got %s, %v", d, err)
+	}
+}
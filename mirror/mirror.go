@@ -0,0 +1,229 @@
+package mirror
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/typing"
+)
+
+// Mirror is a Key-Value Store which writes to a primary and mirrors the writes to secondaries.
+//
+// The writes return the error of the primary, the errors of the secondaries are reported to OnError.
+// Get falls back to the secondaries when the primary fails,
+// while Keys, Size and ForEach are served by the primary.
+type Mirror struct {
+	Primary     typing.KV
+	Secondaries []typing.KV
+	Config      *Config
+
+	queues []chan *op
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// Config is the configuration for Mirror.
+type Config struct {
+	// Async mirrors the writes to the secondaries in the background, in order.
+	// The values must not be modified after Set then.
+	Async bool
+
+	// QueueSize is the number of writes queued per secondary in async mode,
+	// the writes block when it is full, default is 1000.
+	QueueSize int
+
+	// OnError is called with the errors of the backends, optional.
+	OnError func(err *Error)
+}
+
+// Error is an error of a backend.
+type Error struct {
+	// Backend is primary, or secondary[i].
+	Backend string
+	Op      string
+	Key     string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("mirror %s: %s %s: %s", e.Backend, e.Op, e.Key, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// op is a write to mirror.
+type op struct {
+	name   string
+	key    string
+	value  any
+	maxAge []time.Duration
+}
+
+// New returns a new Mirror, which writes to primary and secondaries.
+func New(primary typing.KV, secondaries []typing.KV, cfg ...*Config) *Mirror {
+	cfgX := &Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		cfgX = cfg[0]
+	}
+
+	if cfgX.QueueSize <= 0 {
+		cfgX.QueueSize = 1000
+	}
+
+	m := &Mirror{
+		Primary:     primary,
+		Secondaries: secondaries,
+		Config:      cfgX,
+	}
+
+	if cfgX.Async {
+		m.queues = make([]chan *op, len(secondaries))
+		for i := range secondaries {
+			m.queues[i] = make(chan *op, cfgX.QueueSize)
+			m.wg.Add(1)
+			go m.worker(i)
+		}
+	}
+
+	return m
+}
+
+func secondaryName(i int) string {
+	return fmt.Sprintf("secondary[%d]", i)
+}
+
+func (m *Mirror) report(backend, op, key string, err error) {
+	if err != nil && m.Config.OnError != nil {
+		m.Config.OnError(&Error{Backend: backend, Op: op, Key: key, Err: err})
+	}
+}
+
+func (m *Mirror) worker(i int) {
+	defer m.wg.Done()
+
+	for o := range m.queues[i] {
+		m.report(secondaryName(i), o.name, o.key, apply(m.Secondaries[i], o))
+	}
+}
+
+func apply(kv typing.KV, o *op) error {
+	switch o.name {
+	case "set":
+		return kv.Set(o.key, o.value, o.maxAge...)
+	case "delete":
+		return kv.Delete(o.key)
+	case "clear":
+		return kv.Clear()
+	default:
+		return fmt.Errorf("unknown op %s", o.name)
+	}
+}
+
+// Close waits for the async writes to the secondaries.
+func (m *Mirror) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+
+	for _, q := range m.queues {
+		close(q)
+	}
+	m.wg.Wait()
+
+	return nil
+}
+
+// write applies the write to the primary, and mirrors it to the secondaries.
+func (m *Mirror) write(o *op) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return errors.New("mirror is closed")
+	}
+
+	if err := apply(m.Primary, o); err != nil {
+		m.report("primary", o.name, o.key, err)
+		return err
+	}
+
+	for i, secondary := range m.Secondaries {
+		if m.Config.Async {
+			m.queues[i] <- o
+		} else {
+			m.report(secondaryName(i), o.name, o.key, apply(secondary, o))
+		}
+	}
+
+	return nil
+}
+
+// Set sets the value for the given key, in all backends.
+func (m *Mirror) Set(key string, value any, maxAge ...time.Duration) error {
+	return m.write(&op{name: "set", key: key, value: value, maxAge: maxAge})
+}
+
+// Get returns the value for the given key, from the primary,
+// or from the first secondary which has it, if the primary fails.
+func (m *Mirror) Get(key string, value any) error {
+	err := m.Primary.Get(key, value)
+	if err == nil {
+		return nil
+	}
+	m.report("primary", "get", key, err)
+
+	for i, secondary := range m.Secondaries {
+		if !secondary.Has(key) {
+			continue
+		}
+
+		if errX := secondary.Get(key, value); errX != nil {
+			m.report(secondaryName(i), "get", key, errX)
+			continue
+		}
+
+		return nil
+	}
+
+	return err
+}
+
+// Delete deletes the value for the given key, in all backends.
+func (m *Mirror) Delete(key string) error {
+	return m.write(&op{name: "delete", key: key})
+}
+
+// Has returns true if the given key exists in the primary.
+func (m *Mirror) Has(key string) bool {
+	return m.Primary.Has(key)
+}
+
+// Keys returns the keys of the primary.
+func (m *Mirror) Keys() []string {
+	return m.Primary.Keys()
+}
+
+// Size returns the number of elements in the primary.
+func (m *Mirror) Size() int {
+	return m.Primary.Size()
+}
+
+// Clear removes all elements from all backends.
+func (m *Mirror) Clear() error {
+	return m.write(&op{name: "clear"})
+}
+
+// ForEach calls the given function for each key-value pair in the primary.
+func (m *Mirror) ForEach(f func(string, interface{})) {
+	m.Primary.ForEach(f)
+}
@@ -0,0 +1,147 @@
+package mirror
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/test"
+	"github.com/go-zoox/kv/typing"
+)
+
+// failingKV fails all operations.
+type failingKV struct {
+	typing.KV
+}
+
+var errDown = errors.New("down")
+
+func (failingKV) Set(key string, value any, maxAge ...time.Duration) error { return errDown }
+func (failingKV) Get(key string, value any) error                          { return errDown }
+func (failingKV) Delete(key string) error                                  { return errDown }
+
+func TestKV(t *testing.T) {
+	test.RunTestCases(t, New(memory.New(), []typing.KV{memory.New()}))
+}
+
+func TestAsync(t *testing.T) {
+	secondary := memory.New()
+	m := New(memory.New(), []typing.KV{secondary}, &Config{Async: true})
+	test.RunTestCases(t, m)
+
+	value := "value"
+	m.Set("key", &value)
+	m.Close()
+	if !secondary.Has("key") {
+		t.Error("Expected the async write to be mirrored on Close")
+	}
+	if err := m.Set("key", &value); err == nil {
+		t.Error("Expected write after Close to fail")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	primary := memory.New()
+	secondary := memory.New()
+
+	var mu sync.Mutex
+	var errs []*Error
+	m := New(primary, []typing.KV{secondary}, &Config{
+		OnError: func(err *Error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+
+	value := "value"
+	m.Set("key", &value)
+	if !primary.Has("key") || !secondary.Has("key") {
+		t.Fatal("Expected write to both backends")
+	}
+
+	primary.Delete("key")
+	var got string
+	if err := m.Get("key", &got); err != nil || got != "value" {
+		t.Errorf("Expected fallback to secondary, got %q, %v", got, err)
+	}
+	if len(errs) != 1 || errs[0].Backend != "primary" || errs[0].Op != "get" {
+		t.Errorf("Expected primary get error reported, got %v", errs)
+	}
+
+	m = New(memory.New(), []typing.KV{failingKV{}}, &Config{
+		OnError: func(err *Error) { errs = append(errs, err) },
+	})
+	errs = nil
+	if err := m.Set("key", &value); err != nil {
+		t.Errorf("Expected secondary error not returned, got %v", err)
+	}
+	if len(errs) != 1 || errs[0].Backend != "secondary[0]" || !errors.Is(errs[0], errDown) {
+		t.Errorf("Expected secondary set error reported, got %v", errs)
+	}
+
+	m = New(failingKV{}, []typing.KV{memory.New()})
+	if err := m.Set("key", &value); err != errDown {
+		t.Errorf("Expected primary error returned, got %v", err)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	primary := memory.New()
+	secondary := memory.New()
+	m := New(primary, []typing.KV{secondary})
+
+	a, b, c := "a", "b", "c"
+	primary.Set("a", &a, time.Hour)
+	secondary.Set("b", &b)
+	m.Set("c", &c)
+
+	copied, err := m.Repair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != 2 {
+		t.Errorf("Expected 2 keys copied, got %d", copied)
+	}
+
+	var got string
+	if err := secondary.Get("a", &got); err != nil || got != "a" {
+		t.Errorf("Expected a backfilled in secondary, got %q, %v", got, err)
+	}
+	if d, err := secondary.TTL("a"); err != nil || d <= 0 {
+		t.Errorf("Expected ttl kept, got %s, %v", d, err)
+	}
+	if err := primary.Get("b", &got); err != nil || got != "b" {
+		t.Errorf("Expected b backfilled in primary, got %q, %v", got, err)
+	}
+}
+
+// listingKV lists a key which it does not have.
+type listingKV struct {
+	typing.KV
+}
+
+func (l listingKV) Keys() []string {
+	return append(l.KV.Keys(), "gone")
+}
+
+func TestRepairSkipped(t *testing.T) {
+	primary := memory.New()
+	var errs []*Error
+	m := New(listingKV{primary}, []typing.KV{memory.New()}, &Config{
+		OnError: func(err *Error) { errs = append(errs, err) },
+	})
+
+	value := "value"
+	primary.Set("key", &value)
+
+	copied, err := m.Repair()
+	if err != nil || copied != 1 {
+		t.Fatalf("Expected 1 key copied, got %d, %v", copied, err)
+	}
+	if len(errs) != 1 || errs[0].Key != "gone" || !errors.Is(errs[0], ErrSkipped) {
+		t.Errorf("Expected the gone key reported, got %v", errs)
+	}
+}
@@ -0,0 +1,68 @@
+package mirror
+
+import (
+	"errors"
+
+	"github.com/go-zoox/kv"
+	"github.com/go-zoox/kv/typing"
+)
+
+// ErrSkipped is reported to OnError by Repair for a key which is gone from its source since it was listed,
+// such as expired.
+var ErrSkipped = errors.New("key is gone since listed")
+
+// Repair backfills the keys missing on one side, between the primary and each secondary,
+// and returns the number of copied keys. The values present on both sides are left as is.
+//
+// The time to live is kept if the source backend implements typing.TTL.
+// The errors are reported to OnError, and the first one is returned,
+// while the keys gone since listed are only reported, with ErrSkipped.
+func (m *Mirror) Repair() (int, error) {
+	copied := 0
+	var first error
+	for i, secondary := range m.Secondaries {
+		name := secondaryName(i)
+
+		n, err := m.backfill(m.Primary, secondary, name)
+		copied += n
+		if first == nil {
+			first = err
+		}
+
+		n, err = m.backfill(secondary, m.Primary, "primary")
+		copied += n
+		if first == nil {
+			first = err
+		}
+	}
+
+	return copied, first
+}
+
+// backfill copies the keys of src missing in dst, which is named name.
+func (m *Mirror) backfill(src, dst typing.KV, name string) (int, error) {
+	copied := 0
+	var first error
+	for _, key := range src.Keys() {
+		if dst.Has(key) {
+			continue
+		}
+
+		ok, err := kv.Copy(src, dst, key)
+		if err != nil {
+			m.report(name, "repair", key, err)
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		if !ok {
+			m.report(name, "repair", key, ErrSkipped)
+			continue
+		}
+
+		copied++
+	}
+
+	return copied, first
+}
@@ -0,0 +1,167 @@
+package mongodb
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/go-zoox/kv/test"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeCollection is an in-process Collection,
+// which supports the filters used by MongoDB.
+type fakeCollection struct {
+	sync.Mutex
+	docs map[string]bson.Raw
+}
+
+func newFakeCollection() *fakeCollection {
+	return &fakeCollection{docs: make(map[string]bson.Raw)}
+}
+
+func (c *fakeCollection) match(filter interface{}) []string {
+	id := filter.(bson.M)["_id"]
+	if s, ok := id.(string); ok {
+		if _, ok := c.docs[s]; ok {
+			return []string{s}
+		}
+		return nil
+	}
+
+	re := regexp.MustCompile(id.(bson.M)["$regex"].(string))
+	ids := []string{}
+	for k := range c.docs {
+		if re.MatchString(k) {
+			ids = append(ids, k)
+		}
+	}
+	return ids
+}
+
+func (c *fakeCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+
+	ids := c.match(filter)
+	if len(ids) == 0 {
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+	}
+
+	return mongo.NewSingleResultFromDocument(c.docs[ids[0]], nil, nil)
+}
+
+func (c *fakeCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	docs := []interface{}{}
+	for _, id := range c.match(filter) {
+		docs = append(docs, c.docs[id])
+	}
+
+	return mongo.NewCursorFromDocuments(docs, nil, nil)
+}
+
+func (c *fakeCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	raw, err := bson.Marshal(replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	c.docs[filter.(bson.M)["_id"].(string)] = raw
+	return &mongo.UpdateResult{MatchedCount: 1}, nil
+}
+
+func (c *fakeCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	return c.DeleteMany(ctx, filter)
+}
+
+func (c *fakeCollection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := c.match(filter)
+	for _, id := range ids {
+		delete(c.docs, id)
+	}
+	return &mongo.DeleteResult{DeletedCount: int64(len(ids))}, nil
+}
+
+func TestKV(t *testing.T) {
+	client := NewWithCollection(newFakeCollection(), &Config{
+		Prefix: "go-zoox-test:",
+	})
+
+	test.RunTestCases(t, client)
+}
+
+func TestPrefix(t *testing.T) {
+	collection := newFakeCollection()
+	a := NewWithCollection(collection, &Config{Prefix: "a.b:"})
+	b := NewWithCollection(collection, &Config{Prefix: "axb:"})
+
+	a.Set("key", "a")
+	b.Set("key", "b")
+
+	// "." must not match as a regex wildcard
+	if err := a.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if !b.Has("key") || b.Size() != 1 {
+		t.Error("Expected key in another prefix to be kept")
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	client := NewWithCollection(newFakeCollection(), &Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := client.WithContext(ctx).Set("key", "value"); err == nil {
+		t.Error("Expected error with canceled context")
+	}
+	if err := client.Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the client of the caller is not disconnected
+	mc, err := mongo.NewClient(options.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.client = mc
+	if err := client.WithContext(ctx).Close(); err != nil {
+		t.Errorf("Expected Close of the copy to be a no-op, got %v", err)
+	}
+}
+
+func TestTTLIndex(t *testing.T) {
+	index := ttlIndex()
+	if *index.Options.ExpireAfterSeconds != 0 {
+		t.Errorf("Expected expireAfterSeconds 0, got %d", *index.Options.ExpireAfterSeconds)
+	}
+}
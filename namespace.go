@@ -0,0 +1,118 @@
+package kv
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+	"github.com/go-zoox/kv/typing"
+)
+
+// Namespace returns a KV scoped to the keys of store with the prefix, which is stripped from its keys.
+// Size and Clear only count and remove the keys of the namespace.
+//
+// The keys are listed natively if store implements typing.Prefix and typing.Glob, see KeysWithPrefix.
+// A namespace of a namespace is scoped to both prefixes.
+func Namespace(store typing.KV, prefix string) KV {
+	switch ns := store.(type) {
+	case *namespace:
+		store, prefix = ns.store, ns.prefix+prefix
+	case *ttlNamespace:
+		store, prefix = ns.store, ns.prefix+prefix
+	}
+
+	ns := &namespace{store: store, prefix: prefix}
+	if _, ok := store.(typing.TTL); ok {
+		return &ttlNamespace{ns}
+	}
+
+	return ns
+}
+
+type namespace struct {
+	store  typing.KV
+	prefix string
+}
+
+// ttlNamespace is a namespace of a store implementing typing.TTL.
+type ttlNamespace struct {
+	*namespace
+}
+
+// TTL returns the remaining time to live of the given key, or -1 if it never expires.
+func (n *ttlNamespace) TTL(key string) (time.Duration, error) {
+	return n.store.(typing.TTL).TTL(n.prefix + key)
+}
+
+// Set sets the value for the given key.
+func (n *namespace) Set(key string, value any, maxAge ...time.Duration) error {
+	return n.store.Set(n.prefix+key, value, maxAge...)
+}
+
+// Replace sets the value for the given key, which never expires, see Replace.
+func (n *namespace) Replace(key string, value any) error {
+	return Replace(n.store, n.prefix+key, value)
+}
+
+// Get returns the value for the given key.
+func (n *namespace) Get(key string, value any) error {
+	return n.store.Get(n.prefix+key, value)
+}
+
+// Delete deletes the value for the given key.
+func (n *namespace) Delete(key string) error {
+	return n.store.Delete(n.prefix + key)
+}
+
+// Has returns true if the given key exists in the namespace.
+func (n *namespace) Has(key string) bool {
+	return n.store.Has(n.prefix + key)
+}
+
+// Keys returns the keys of the namespace, without the prefix.
+func (n *namespace) Keys() []string {
+	return n.KeysWithPrefix("")
+}
+
+// KeysWithPrefix returns the keys of the namespace starting with the given prefix, without the prefix of the namespace.
+func (n *namespace) KeysWithPrefix(prefix string) []string {
+	return n.strip(KeysWithPrefix(n.store, n.prefix+prefix))
+}
+
+// KeysMatching returns the keys of the namespace matching the glob pattern, without the prefix of the namespace.
+func (n *namespace) KeysMatching(pattern string) []string {
+	return n.strip(KeysMatching(n.store, glob.Escape(n.prefix)+pattern))
+}
+
+func (n *namespace) strip(keys []string) []string {
+	stripped := make([]string, len(keys))
+	for i, key := range keys {
+		stripped[i] = key[len(n.prefix):]
+	}
+
+	return stripped
+}
+
+// DeletePrefix deletes the keys of the namespace starting with the given prefix.
+func (n *namespace) DeletePrefix(prefix string) error {
+	return DeletePrefix(n.store, n.prefix+prefix)
+}
+
+// Size returns the number of keys in the namespace.
+func (n *namespace) Size() int {
+	return len(n.Keys())
+}
+
+// Clear removes the keys of the namespace.
+func (n *namespace) Clear() error {
+	return n.DeletePrefix("")
+}
+
+// ForEach calls the given function for each key-value pair in the namespace, without the prefix.
+func (n *namespace) ForEach(f func(string, any)) {
+	n.store.ForEach(func(key string, value any) {
+		if strings.HasPrefix(key, n.prefix) {
+			f(key[len(n.prefix):], value)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package kv
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/typing"
+)
+
+// keysOnly hides the native prefix support of the store.
+type keysOnly struct {
+	typing.KV
+}
+
+func TestNamespace(t *testing.T) {
+	fsStore, _ := fs.New(&fs.FileSystemOptions{Dir: t.TempDir()})
+	stores := map[string]typing.KV{
+		"memory":    memory.New(),
+		"fs":        fsStore,
+		"keys only": &keysOnly{memory.New()},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			// memory expects pointers
+			one, two, three := "1", "2", "3"
+			store.Set("other", &one)
+			sessions := Namespace(store, "sessions:")
+			sessions.Set("a", &one)
+			sessions.Set("b", &two, time.Hour)
+
+			users := Namespace(Namespace(store, "app:"), "users:")
+			users.Set("a", &three)
+
+			keys := sessions.Keys()
+			sort.Strings(keys)
+			if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" || sessions.Size() != 2 {
+				t.Errorf("Expected sessions a and b, got %v", keys)
+			}
+			if keys := users.Keys(); len(keys) != 1 || keys[0] != "a" || !store.Has("app:users:a") {
+				t.Errorf("Expected nested namespace key, got %v", keys)
+			}
+
+			if keys := sessions.(typing.Glob).KeysMatching("?"); len(keys) != 2 {
+				t.Errorf("Expected 2 keys matching ?, got %v", keys)
+			}
+
+			var value string
+			if err := sessions.Get("a", &value); err != nil || value != "1" {
+				t.Errorf("Expected 1, got %q, %v", value, err)
+			}
+
+			_, ok := sessions.(typing.TTL)
+			if _, hasTTL := store.(typing.TTL); ok != hasTTL {
+				t.Errorf("Expected the namespace to implement TTL as the store, got %v", ok)
+			}
+
+			n := 0
+			sessions.ForEach(func(key string, value any) {
+				n++
+			})
+			if n != 2 {
+				t.Errorf("Expected ForEach over 2 keys, got %d", n)
+			}
+
+			if err := sessions.Clear(); err != nil {
+				t.Fatal(err)
+			}
+			if sessions.Size() != 0 || store.Size() != 2 {
+				t.Errorf("Expected only the namespace to be cleared, got %d keys left", store.Size())
+			}
+		})
+	}
+}
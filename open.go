@@ -0,0 +1,124 @@
+package kv
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/redis"
+	"github.com/go-zoox/kv/typing"
+)
+
+// Open returns a new KV from a DSN, whose scheme is the engine, such as:
+//
+//	memory://?max=10000
+//	file:///var/cache/app
+//	redis://:password@localhost:6379/0?prefix=app:
+func Open(dsn string) (KV, error) {
+	cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(cfg)
+}
+
+// ParseDSN parses a DSN into the Config of its engine, see Open.
+// For the engines without a built-in DSN format, the Config is the *url.URL of the DSN.
+func ParseDSN(dsn string) (*typing.Config, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, NewError(ErrInvalidDSN, err.Error())
+	}
+
+	switch u.Scheme {
+	case "memory":
+		return parseMemoryDSN(u)
+	case "file":
+		return parseFileDSN(u)
+	case "redis", "rediss":
+		return parseRedisDSN(u)
+	case "":
+		return nil, NewError(ErrInvalidDSN, "scheme is required")
+	default:
+		// the registered engines get the DSN as is
+		if _, ok := lookup(u.Scheme); ok {
+			return &typing.Config{Engine: u.Scheme, Config: u}, nil
+		}
+
+		return nil, NewError(ErrUnknownEngine, u.Scheme)
+	}
+}
+
+// options returns the query of the DSN, and fails on the options not in allowed.
+func options(u *url.URL, allowed ...string) (url.Values, error) {
+	q := u.Query()
+	for name := range q {
+		known := false
+		for _, a := range allowed {
+			if name == a {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return nil, NewError(ErrInvalidDSN, fmt.Sprintf("unknown %s option %q", u.Scheme, name))
+		}
+	}
+
+	return q, nil
+}
+
+func parseMemoryDSN(u *url.URL) (*typing.Config, error) {
+	q, err := options(u, "max")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &memory.Config{}
+	if v := q.Get("max"); v != "" {
+		if cfg.MaxSize, err = strconv.Atoi(v); err != nil || cfg.MaxSize < 0 {
+			return nil, NewError(ErrInvalidDSN, fmt.Sprintf("max must be a non-negative integer, got %q", v))
+		}
+	}
+
+	return &typing.Config{Engine: "memory", Config: cfg}, nil
+}
+
+// parseFileDSN parses file:///absolute/dir, or file://relative/dir.
+func parseFileDSN(u *url.URL) (*typing.Config, error) {
+	if _, err := options(u); err != nil {
+		return nil, err
+	}
+
+	dir := u.Host + u.Path
+	if dir == "" {
+		return nil, NewError(ErrInvalidDSN, "file directory is required")
+	}
+
+	return &typing.Config{Engine: "filesystem", Config: &fs.FileSystemOptions{Dir: dir}}, nil
+}
+
+// parseRedisDSN parses the redis URL, with the prefix option,
+// the other options are passed to go-redis, such as dial_timeout.
+func parseRedisDSN(u *url.URL) (*typing.Config, error) {
+	if u.Host == "" {
+		return nil, NewError(ErrInvalidDSN, "redis host is required")
+	}
+
+	q := u.Query()
+	prefix := q.Get("prefix")
+	if prefix == "" {
+		return nil, NewError(ErrInvalidDSN, "redis prefix is required")
+	}
+
+	// go-redis fails on unknown options
+	q.Del("prefix")
+	uri := *u
+	uri.RawQuery = q.Encode()
+
+	return &typing.Config{Engine: "redis", Config: &redis.Config{URI: uri.String(), Prefix: prefix}}, nil
+}
@@ -0,0 +1,79 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/redis"
+	"github.com/go-zoox/kv/typing"
+)
+
+func TestOpen(t *testing.T) {
+	client, err := Open("memory://?max=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := client.(*memory.Memory); m.Config.MaxSize != 2 {
+		t.Errorf("Expected max size 2, got %d", m.Config.MaxSize)
+	}
+
+	dir := t.TempDir()
+	client, err = Open("file://" + dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := "value"
+	client.Set("key", &value)
+	if client.Size() != 1 {
+		t.Errorf("Expected size 1, got %d", client.Size())
+	}
+
+	client, err = Open("redis://:pass@localhost:6379/1?prefix=app:&dial_timeout=3s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := client.(*redis.Redis); r.Config.Prefix != "app:" || r.Core.Options().DB != 1 || r.Core.Options().Password != "pass" {
+		t.Errorf("Unexpected redis config %+v", r.Config)
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	cfg, err := ParseDSN("file://relative/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := cfg.Config.(*fs.FileSystemOptions); cfg.Engine != "filesystem" || c.Dir != "relative/dir" {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+
+	for _, dsn := range []string{
+		"",
+		"unknown://",
+		"memory://?max=-1",
+		"memory://?max=many",
+		"memory://?size=1",
+		"file://",
+		"file:///tmp?mode=1",
+		"redis://localhost:6379",
+		"redis://?prefix=app:",
+		"%gh&%ij",
+	} {
+		if _, err := ParseDSN(dsn); err == nil {
+			t.Errorf("Expected %q to be invalid", dsn)
+		}
+	}
+
+	if _, err := Open("redis://localhost:6379?prefix=app:&unknown=1"); err == nil {
+		t.Error("Expected unknown go-redis option to be invalid")
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	if _, err := New(&typing.Config{Engine: "redis", Config: &fs.FileSystemOptions{}}); err == nil {
+		t.Error("Expected config of the wrong type to be an error")
+	}
+	if _, err := New(&typing.Config{Engine: "filesystem", Config: "dir"}); err == nil {
+		t.Error("Expected config of the wrong type to be an error")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/go-zoox/kv/glob"
+	"github.com/go-zoox/kv/typing"
 )
 
 // Redis is a Key-Value Store in Redis
@@ -113,6 +115,19 @@ func (m *Redis) Set(key string, value any, maxAge ...time.Duration) error {
 	return m.Core.Set(m.Ctx, keyX, valueX, goredis.KeepTTL).Err()
 }
 
+// Replace sets the value for the given key, which never expires, with a SET without KEEPTTL.
+func (m *Redis) Replace(key string, value any) error {
+	m.Lock()
+	defer m.Unlock()
+
+	valueX, err := m.encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	return m.Core.Set(m.Ctx, m.getKey(key), valueX, 0).Err()
+}
+
 // Get returns the value for the given key.
 func (m *Redis) Get(key string, value any) error {
 	m.RLock()
@@ -123,6 +138,24 @@ func (m *Redis) Get(key string, value any) error {
 	return m.decodeValue([]byte(valueX), value)
 }
 
+// TTL returns the remaining time to live of the given key, or -1 if it never expires.
+func (m *Redis) TTL(key string) (time.Duration, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	ttl, err := m.Core.TTL(m.Ctx, m.getKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// go-redis returns -2 for a missing key, and -1 for a key without expiry
+	if ttl == -2 {
+		return 0, fmt.Errorf("key %s %w", key, typing.ErrNotFound)
+	}
+
+	return ttl, nil
+}
+
 // Delete deletes the value for the given key.
 func (m *Redis) Delete(key string) error {
 	m.Lock()
@@ -144,48 +177,103 @@ func (m *Redis) Has(key string) bool {
 	return length != 0
 }
 
-// Keys returns the keys of the kv.
-func (m *Redis) Keys() []string {
-	m.RLock()
-	defer m.RUnlock()
+// scanCount is the number of keys per SCAN, and per DEL in DeletePrefix.
+const scanCount = 100
+
+// scan calls fn with the keys matching the pattern, without the configured prefix, a page at a time.
+// It uses SCAN, which does not block the server like KEYS, but may return a key more than once.
+func (m *Redis) scan(pattern string, fn func(keys []string) error) error {
+	pattern = glob.Escape(m.Config.Prefix) + pattern
+
+	var cursor uint64
+	for {
+		keys, next, err := m.Core.Scan(m.Ctx, cursor, pattern, scanCount).Result()
+		if err != nil {
+			return err
+		}
+
+		for i, k := range keys {
+			keys[i] = k[len(m.Config.Prefix):]
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
 
-	res := m.Core.Keys(m.Ctx, m.Config.Prefix+"*")
-	if res.Err() != nil {
-		panic(res.Err())
+		if next == 0 {
+			return nil
+		}
+		cursor = next
 	}
+}
 
-	keys := make([]string, len(res.Val()))
-	for i, k := range res.Val() {
-		keys[i] = k[len(m.Config.Prefix):]
+// keysMatching returns the unique keys matching the pattern.
+func (m *Redis) keysMatching(pattern string) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	err := m.scan(pattern, func(page []string) error {
+		for _, key := range page {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
 	}
+
 	return keys
 }
 
-// Size returns the number of elements in the kv.
-func (m *Redis) Size() int {
+// Keys returns the keys of the kv.
+func (m *Redis) Keys() []string {
 	m.RLock()
 	defer m.RUnlock()
 
-	return len(m.Keys())
+	return m.keysMatching("*")
 }
 
-// Clear removes all elements from the kv.
-func (m *Redis) Clear() error {
-	keys := m.Keys()
-	if len(keys) == 0 {
-		return nil
-	}
+// KeysWithPrefix returns the keys starting with the given prefix.
+func (m *Redis) KeysWithPrefix(prefix string) []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.keysMatching(glob.Escape(prefix) + "*")
+}
 
+// KeysMatching returns the keys matching the glob pattern, by SCAN with MATCH.
+func (m *Redis) KeysMatching(pattern string) []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.keysMatching(pattern)
+}
+
+// DeletePrefix deletes the keys starting with the given prefix, a page of SCAN per DEL.
+func (m *Redis) DeletePrefix(prefix string) error {
 	m.Lock()
 	defer m.Unlock()
 
-	for _, key := range keys {
-		// m.Delete(key)
-		if err := m.Core.Del(m.Ctx, m.getKey(key)).Err(); err != nil {
-			return err
+	return m.scan(glob.Escape(prefix)+"*", func(keys []string) error {
+		for i, key := range keys {
+			keys[i] = m.getKey(key)
 		}
-	}
-	return nil
+
+		return m.Core.Del(m.Ctx, keys...).Err()
+	})
+}
+
+// Size returns the number of elements in the kv.
+func (m *Redis) Size() int {
+	return len(m.Keys())
+}
+
+// Clear removes all elements from the kv.
+func (m *Redis) Clear() error {
+	return m.DeletePrefix("")
 }
 
 // ForEach calls the given function for each key-value pair in the kv.
@@ -1,13 +1,29 @@
 package redis
 
 import (
+	"net"
 	"testing"
 
 	"github.com/go-zoox/dotenv"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/server/resp"
 	"github.com/go-zoox/kv/test"
 )
 
-func createClient() *Redis {
+// createServer starts an in-process redis protocol server in front of a memory kv,
+// used when REDIS_URI is not set.
+func createServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go resp.New(memory.New()).Serve(l)
+	return "redis://" + l.Addr().String()
+}
+
+func createClient(t *testing.T) *Redis {
 	// var cfg struct {
 	// 	URI string `env:"REDIS_URI"`
 	// }
@@ -15,7 +31,10 @@ func createClient() *Redis {
 	// 	panic(err)
 	// }
 
-	redisURI := dotenv.Get("REDIS_URI", "redis://localhost:6379")
+	redisURI := dotenv.Get("REDIS_URI")
+	if redisURI == "" {
+		redisURI = createServer(t)
+	}
 
 	client, err := New(&Config{
 		URI:    redisURI,
@@ -29,5 +48,5 @@ func createClient() *Redis {
 }
 
 func TestKV(t *testing.T) {
-	test.RunTestCases(t, createClient())
+	test.RunTestCases(t, createClient(t))
 }
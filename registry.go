@@ -0,0 +1,94 @@
+package kv
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory creates a KV from the engine config, which is nil if not set.
+type Factory func(config any) (KV, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+	// configs creates the empty config of the built-in engines, for NewFromEnv.
+	configs = make(map[string]func() any)
+)
+
+// Register makes an engine available by name to New and Open.
+// Open passes the *url.URL of the DSN as config to the engines without a built-in DSN format.
+// It panics if the factory is nil or the name is already registered, like database/sql.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("kv: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("kv: Register called twice for engine " + name)
+	}
+
+	factories[name] = factory
+}
+
+// Engines returns the sorted names of the registered engines.
+func Engines() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// newConfig returns a new empty config of the engine, if known.
+func newConfig(name string) (any, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	create, ok := configs[name]
+	if !ok {
+		return nil, false
+	}
+
+	return create(), true
+}
+
+func lookup(name string) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	factory, ok := factories[name]
+	return factory, ok
+}
+
+// register registers a built-in engine, whose config must be a *T,
+// and could be nil if optional.
+func register[T any](name string, optional bool, create func(cfg *T) (KV, error)) {
+	Register(name, func(config any) (KV, error) {
+		var cfg *T
+		if config != nil {
+			var ok bool
+			if cfg, ok = config.(*T); !ok {
+				return nil, NewError(ErrConfigInvalid, fmt.Sprintf("%s expects %T, got %T", name, cfg, config))
+			}
+		}
+
+		// a nil *T is not set either
+		if cfg == nil && !optional {
+			return nil, NewError(ErrConfigNotSet, name)
+		}
+
+		return create(cfg)
+	})
+
+	factoriesMu.Lock()
+	configs[name] = func() any { return new(T) }
+	factoriesMu.Unlock()
+}
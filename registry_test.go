@@ -0,0 +1,64 @@
+package kv
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/redis"
+)
+
+func TestRegister(t *testing.T) {
+	var got any
+	Register("test-engine", func(config any) (KV, error) {
+		got = config
+		return memory.New(), nil
+	})
+
+	found := false
+	for _, name := range Engines() {
+		if name == "test-engine" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected test-engine in %v", Engines())
+	}
+
+	if _, err := New(&Config{Engine: "test-engine", Config: "config"}); err != nil || got != "config" {
+		t.Errorf("Expected the factory to get the config, got %v, %v", got, err)
+	}
+
+	if _, err := Open("test-engine://host/path?option=1"); err != nil {
+		t.Fatal(err)
+	}
+	if u, ok := got.(*url.URL); !ok || u.Host != "host" || u.Query().Get("option") != "1" {
+		t.Errorf("Expected the factory to get the DSN, got %v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Register twice to panic")
+		}
+	}()
+	Register("memory", func(config any) (KV, error) { return nil, nil })
+}
+
+func TestTypedNilConfig(t *testing.T) {
+	var cfg *memory.Config
+	if _, err := New(&Config{Engine: "memory", Config: cfg}); err != nil {
+		t.Errorf("Expected a nil config to be optional for memory, got %v", err)
+	}
+
+	var redisCfg *redis.Config
+	_, err := New(&Config{Engine: "redis", Config: redisCfg})
+	if e, ok := err.(*Error); !ok || e.Type != ErrConfigNotSet {
+		t.Errorf("Expected the config of redis not set, got %v", err)
+	}
+}
+
+func TestUnknownEngine(t *testing.T) {
+	if _, err := New(&Config{Engine: "unknown"}); err == nil {
+		t.Error("Expected unknown engine to be an error")
+	}
+}
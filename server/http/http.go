@@ -0,0 +1,169 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	gohttp "net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-zoox/kv/typing"
+)
+
+// HeaderTTL is the header to set the max age of a value on PUT,
+// the same as the ttl query parameter.
+const HeaderTTL = "X-KV-TTL"
+
+// Server exposes a KV over a REST API:
+//
+//	GET    /keys/{key}    get the value
+//	PUT    /keys/{key}    set the value, with ttl in X-KV-TTL header or ttl query
+//	DELETE /keys/{key}    delete the value
+//	HEAD   /keys/{key}    check whether the key exists
+//	GET    /keys?prefix=  list the keys, optionally filtered by prefix
+//	DELETE /keys          clear the kv
+//
+// Values are JSON, ttl is a duration such as 500ms or 10m, or a number of seconds.
+type Server struct {
+	KV typing.KV
+}
+
+// New returns a new Server.
+func New(kv typing.KV) *Server {
+	return &Server{
+		KV: kv,
+	}
+}
+
+// ParseTTL parses a ttl, as a duration such as 500ms or a number of seconds.
+func ParseTTL(s string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// ServeHTTP serves the REST API.
+func (s *Server) ServeHTTP(w gohttp.ResponseWriter, r *gohttp.Request) {
+	path := r.URL.EscapedPath()
+	switch {
+	case path == "/keys":
+		s.serveKeys(w, r)
+	case strings.HasPrefix(path, "/keys/"):
+		key, err := url.PathUnescape(strings.TrimPrefix(path, "/keys/"))
+		if err != nil {
+			gohttp.Error(w, err.Error(), gohttp.StatusBadRequest)
+			return
+		}
+		if key == "" {
+			// not the keys, so that DELETE /keys/ does not clear them all
+			gohttp.Error(w, "key is required", gohttp.StatusBadRequest)
+			return
+		}
+
+		s.serveKey(w, r, key)
+	default:
+		gohttp.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveKeys(w gohttp.ResponseWriter, r *gohttp.Request) {
+	switch r.Method {
+	case gohttp.MethodGet:
+		prefix := r.URL.Query().Get("prefix")
+		keys := []string{}
+		for _, key := range s.KV.Keys() {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(keys)
+	case gohttp.MethodDelete:
+		if err := s.KV.Clear(); err != nil {
+			gohttp.Error(w, err.Error(), gohttp.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(gohttp.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		gohttp.Error(w, "method not allowed", gohttp.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveKey(w gohttp.ResponseWriter, r *gohttp.Request, key string) {
+	switch r.Method {
+	case gohttp.MethodGet:
+		if !s.KV.Has(key) {
+			gohttp.NotFound(w, r)
+			return
+		}
+
+		var value json.RawMessage
+		if err := s.KV.Get(key, &value); err != nil {
+			gohttp.Error(w, err.Error(), gohttp.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(value)
+	case gohttp.MethodHead:
+		if !s.KV.Has(key) {
+			w.WriteHeader(gohttp.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(gohttp.StatusOK)
+	case gohttp.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			gohttp.Error(w, err.Error(), gohttp.StatusBadRequest)
+			return
+		}
+		if !json.Valid(body) {
+			gohttp.Error(w, "body must be json", gohttp.StatusBadRequest)
+			return
+		}
+
+		ttl := r.Header.Get(HeaderTTL)
+		if ttl == "" {
+			ttl = r.URL.Query().Get("ttl")
+		}
+
+		value := json.RawMessage(body)
+		if ttl != "" {
+			maxAge, err := ParseTTL(ttl)
+			if err != nil || maxAge <= 0 {
+				gohttp.Error(w, "invalid ttl: "+ttl, gohttp.StatusBadRequest)
+				return
+			}
+
+			err = s.KV.Set(key, &value, maxAge)
+		} else {
+			err = s.KV.Set(key, &value)
+		}
+		if err != nil {
+			gohttp.Error(w, err.Error(), gohttp.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(gohttp.StatusNoContent)
+	case gohttp.MethodDelete:
+		if err := s.KV.Delete(key); err != nil {
+			gohttp.Error(w, err.Error(), gohttp.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(gohttp.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		gohttp.Error(w, "method not allowed", gohttp.StatusMethodNotAllowed)
+	}
+}
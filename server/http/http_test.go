@@ -0,0 +1,79 @@
+package http
+
+import (
+	"io"
+	gohttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+)
+
+func request(t *testing.T, s *Server, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer(t *testing.T) {
+	s := New(memory.New())
+
+	if w := request(t, s, gohttp.MethodPut, "/keys/a", `{"name":"zero"}`); w.Code != gohttp.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := request(t, s, gohttp.MethodGet, "/keys/a", ""); w.Code != gohttp.StatusOK || w.Body.String() != `{"name":"zero"}` {
+		t.Errorf("Expected value, got %d %s", w.Code, w.Body.String())
+	}
+	if w := request(t, s, gohttp.MethodHead, "/keys/a", ""); w.Code != gohttp.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if w := request(t, s, gohttp.MethodPut, "/keys/a", "not json"); w.Code != gohttp.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	if w := request(t, s, gohttp.MethodPut, "/keys/b?ttl=50ms", `1`); w.Code != gohttp.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := request(t, s, gohttp.MethodPut, "/keys/c", `2`, HeaderTTL, "bad"); w.Code != gohttp.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+	if w := request(t, s, gohttp.MethodGet, "/keys", ""); w.Body.String() != "[\"a\",\"b\"]\n" {
+		t.Errorf("Expected keys a,b, got %s", w.Body.String())
+	}
+
+	if w := request(t, s, gohttp.MethodDelete, "/keys/", ""); w.Code != gohttp.StatusBadRequest || !s.KV.Has("a") {
+		t.Errorf("Expected status 400 for the empty key, got %d", w.Code)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if w := request(t, s, gohttp.MethodGet, "/keys/b", ""); w.Code != gohttp.StatusNotFound {
+		t.Errorf("Expected status 404 after ttl, got %d", w.Code)
+	}
+
+	if w := request(t, s, gohttp.MethodDelete, "/keys", ""); w.Code != gohttp.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if w := request(t, s, gohttp.MethodHead, "/keys/a", ""); w.Code != gohttp.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	for s, expected := range map[string]time.Duration{"10": 10 * time.Second, "500ms": 500 * time.Millisecond} {
+		if d, err := ParseTTL(s); err != nil || d != expected {
+			t.Errorf("Expected %s for %s, got %s (%v)", expected, s, d, err)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+package memcached
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/typing"
+)
+
+// maxRelativeExptime is the max exptime in seconds treated as relative,
+// larger values are unix timestamps, like memcached.
+const maxRelativeExptime = 60 * 60 * 24 * 30
+
+// maxKeyLength is the max length of a key.
+const maxKeyLength = 250
+
+// maxValueSize is the max size of a value, the same as memcached item_size_max.
+const maxValueSize = 1024 * 1024
+
+// Server exposes a KV over the memcached text protocol.
+//
+// Supported commands are get, gets, set, add, replace, cas, delete, touch, incr, decr,
+// flush_all, version, quit, lru_crawler metadump all to list the keys,
+// and mg with the flags t and v, to get the remaining time to live and the value.
+type Server struct {
+	sync.Mutex
+	KV typing.KV
+
+	cas uint64
+}
+
+// entry is the value stored in the KV for each key.
+type entry struct {
+	Value     []byte
+	Flags     uint32
+	ExpiresAt int64
+	CAS       uint64
+}
+
+// New returns a new Server.
+func New(kv typing.KV) *Server {
+	return &Server{
+		KV: kv,
+	}
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+// ListenAndServe listens on the TCP address addr, and serves the connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l, and serves each one in a goroutine.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		go s.ServeConn(c)
+	}
+}
+
+// ServeConn serves commands on a connection, until the client quits or closes it.
+func (s *Server) ServeConn(c net.Conn) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			w.WriteString("ERROR\r\n")
+			w.Flush()
+			continue
+		}
+
+		if fields[0] == "quit" {
+			w.Flush()
+			return
+		}
+
+		if err := s.exec(r, w, fields); err != nil {
+			return
+		}
+
+		// flush once the pipelined commands are all handled
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// expiresAt converts a memcached exptime into unix milliseconds, 0 for never,
+// and -1 for already expired.
+func expiresAt(exptime int64) int64 {
+	switch {
+	case exptime == 0:
+		return 0
+	case exptime < 0:
+		return -1
+	case exptime <= maxRelativeExptime:
+		return now() + exptime*1000
+	default:
+		return exptime * 1000
+	}
+}
+
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
+
+// load returns the entry of the key, or nil if it does not exist.
+func (s *Server) load(key string) *entry {
+	if !s.KV.Has(key) {
+		return nil
+	}
+
+	var e entry
+	if err := s.KV.Get(key, &e); err != nil {
+		return nil
+	}
+	if e.ExpiresAt > 0 && e.ExpiresAt <= now() {
+		return nil
+	}
+
+	return &e
+}
+
+// store stores the entry of the key with a new cas unique.
+func (s *Server) store(key string, e *entry) error {
+	s.cas++
+	e.CAS = s.cas
+
+	if e.ExpiresAt < 0 || (e.ExpiresAt > 0 && e.ExpiresAt <= now()) {
+		return s.KV.Delete(key)
+	}
+
+	if e.ExpiresAt > 0 {
+		return s.KV.Set(key, e, time.Duration(e.ExpiresAt-now())*time.Millisecond)
+	}
+
+	// memcached replaces the origin expiry
+	return typing.Replace(s.KV, key, e)
+}
+
+func (s *Server) exec(r *bufio.Reader, w *bufio.Writer, fields []string) error {
+	cmd := fields[0]
+	args := fields[1:]
+
+	noreply := false
+	if n := len(args); n > 0 && args[n-1] == "noreply" {
+		switch cmd {
+		case "set", "add", "replace", "cas", "delete", "touch", "incr", "decr", "flush_all":
+			noreply = true
+			args = args[:n-1]
+		}
+	}
+
+	var reply string
+	switch cmd {
+	case "get", "gets":
+		if len(args) == 0 {
+			reply = "ERROR"
+			break
+		}
+
+		s.get(w, args, cmd == "gets")
+		return nil
+
+	case "set", "add", "replace", "cas":
+		var err error
+		reply, err = s.storage(r, cmd, args)
+		if err != nil {
+			return err
+		}
+
+	case "delete":
+		reply = s.delete(args)
+
+	case "touch":
+		reply = s.touch(args)
+
+	case "mg":
+		if len(args) == 0 {
+			reply = "CLIENT_ERROR bad command line format"
+			break
+		}
+
+		s.metaGet(w, args[0], args[1:])
+		return nil
+
+	case "incr", "decr":
+		reply = s.incr(args, cmd == "decr")
+
+	case "flush_all":
+		s.Lock()
+		err := s.KV.Clear()
+		s.Unlock()
+		if err != nil {
+			reply = "SERVER_ERROR " + err.Error()
+		} else {
+			reply = "OK"
+		}
+
+	case "version":
+		reply = "VERSION 1.6.0"
+
+	case "lru_crawler":
+		if len(args) != 2 || args[0] != "metadump" || args[1] != "all" {
+			reply = "CLIENT_ERROR bad command line format"
+			break
+		}
+
+		s.metadump(w)
+		return nil
+
+	default:
+		reply = "ERROR"
+	}
+
+	if !noreply {
+		w.WriteString(reply + "\r\n")
+	}
+	return nil
+}
+
+func (s *Server) get(w *bufio.Writer, keys []string, withCAS bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, key := range keys {
+		e := s.load(key)
+		if e == nil {
+			continue
+		}
+
+		if withCAS {
+			fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, e.Flags, len(e.Value), e.CAS)
+		} else {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, e.Flags, len(e.Value))
+		}
+		w.Write(e.Value)
+		w.WriteString("\r\n")
+	}
+
+	w.WriteString("END\r\n")
+}
+
+// storage handles set, add, replace and cas.
+func (s *Server) storage(r *bufio.Reader, cmd string, args []string) (string, error) {
+	n := 4
+	if cmd == "cas" {
+		n = 5
+	}
+	if len(args) != n {
+		return "ERROR", nil
+	}
+
+	key := args[0]
+	flags, err1 := strconv.ParseUint(args[1], 10, 32)
+	exptime, err2 := strconv.ParseInt(args[2], 10, 64)
+	size, err3 := strconv.Atoi(args[3])
+	if err1 != nil || err2 != nil || err3 != nil || size < 0 || !validKey(key) {
+		return "CLIENT_ERROR bad command line format", nil
+	}
+	if size > maxValueSize {
+		// swallow the data, so that the connection stays usable
+		if _, err := io.CopyN(io.Discard, r, int64(size)+2); err != nil {
+			return "", err
+		}
+		return "SERVER_ERROR object too large for cache", nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	if data[size] != '\r' || data[size+1] != '\n' {
+		// swallow the rest of the line, which is not a command
+		if data[size+1] != '\n' {
+			if _, err := r.ReadString('\n'); err != nil {
+				return "", err
+			}
+		}
+		return "CLIENT_ERROR bad data chunk", nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	old := s.load(key)
+	switch cmd {
+	case "add":
+		if old != nil {
+			return "NOT_STORED", nil
+		}
+	case "replace":
+		if old == nil {
+			return "NOT_STORED", nil
+		}
+	case "cas":
+		unique, err := strconv.ParseUint(args[4], 10, 64)
+		if err != nil {
+			return "CLIENT_ERROR bad command line format", nil
+		}
+		if old == nil {
+			return "NOT_FOUND", nil
+		}
+		if old.CAS != unique {
+			return "EXISTS", nil
+		}
+	}
+
+	e := &entry{
+		Value:     data[:size],
+		Flags:     uint32(flags),
+		ExpiresAt: expiresAt(exptime),
+	}
+	if err := s.store(key, e); err != nil {
+		return "SERVER_ERROR " + err.Error(), nil
+	}
+
+	return "STORED", nil
+}
+
+func (s *Server) delete(args []string) string {
+	if len(args) != 1 {
+		return "CLIENT_ERROR bad command line format"
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.load(args[0]) == nil {
+		return "NOT_FOUND"
+	}
+	if err := s.KV.Delete(args[0]); err != nil {
+		return "SERVER_ERROR " + err.Error()
+	}
+
+	return "DELETED"
+}
+
+func (s *Server) touch(args []string) string {
+	if len(args) != 2 {
+		return "ERROR"
+	}
+
+	exptime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR invalid exptime argument"
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	e := s.load(args[0])
+	if e == nil {
+		return "NOT_FOUND"
+	}
+
+	e.ExpiresAt = expiresAt(exptime)
+	if err := s.store(args[0], e); err != nil {
+		return "SERVER_ERROR " + err.Error()
+	}
+
+	return "TOUCHED"
+}
+
+// metaGet handles mg, with the flags t, for the remaining time to live in seconds or -1, and v, for the value.
+func (s *Server) metaGet(w *bufio.Writer, key string, flags []string) {
+	withValue := false
+	withTTL := false
+	for _, flag := range flags {
+		switch flag {
+		case "v":
+			withValue = true
+		case "t":
+			withTTL = true
+		default:
+			w.WriteString("CLIENT_ERROR invalid flag\r\n")
+			return
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	e := s.load(key)
+	if e == nil {
+		w.WriteString("EN\r\n")
+		return
+	}
+
+	ret := ""
+	if withTTL {
+		ttl := int64(-1)
+		if e.ExpiresAt > 0 {
+			ttl = (e.ExpiresAt - now() + 999) / 1000
+		}
+		ret = fmt.Sprintf(" t%d", ttl)
+	}
+
+	if !withValue {
+		w.WriteString("HD" + ret + "\r\n")
+		return
+	}
+
+	fmt.Fprintf(w, "VA %d%s\r\n", len(e.Value), ret)
+	w.Write(e.Value)
+	w.WriteString("\r\n")
+}
+
+func (s *Server) incr(args []string, decr bool) string {
+	if len(args) != 2 {
+		return "ERROR"
+	}
+
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR invalid numeric delta argument"
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	e := s.load(args[0])
+	if e == nil {
+		return "NOT_FOUND"
+	}
+
+	n, err := strconv.ParseUint(strings.TrimSpace(string(e.Value)), 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR cannot increment or decrement non-numeric value"
+	}
+
+	if decr {
+		// decr floors at 0, incr wraps around 64 bits, like memcached
+		if delta > n {
+			n = 0
+		} else {
+			n -= delta
+		}
+	} else {
+		n += delta
+	}
+
+	e.Value = []byte(strconv.FormatUint(n, 10))
+	if err := s.store(args[0], e); err != nil {
+		return "SERVER_ERROR " + err.Error()
+	}
+
+	return string(e.Value)
+}
+
+// metadump writes the keys, in the lru_crawler metadump format of memcached.
+func (s *Server) metadump(w *bufio.Writer) {
+	s.Lock()
+	defer s.Unlock()
+
+	keys := s.KV.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		e := s.load(key)
+		if e == nil {
+			continue
+		}
+
+		exp := int64(-1)
+		if e.ExpiresAt > 0 {
+			exp = e.ExpiresAt / 1000
+		}
+		fmt.Fprintf(w, "key=%s exp=%d cas=%d size=%d\r\n", url.QueryEscape(key), exp, e.CAS, len(e.Value))
+	}
+
+	w.WriteString("END\r\n")
+}
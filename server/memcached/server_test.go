@@ -0,0 +1,113 @@
+package memcached
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+)
+
+type conn struct {
+	t *testing.T
+	net.Conn
+	r *bufio.Reader
+}
+
+func createConn(t *testing.T) *conn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go New(memory.New()).Serve(l)
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+
+	return &conn{t: t, Conn: c, r: bufio.NewReader(c)}
+}
+
+// expect sends the command, and checks the reply lines.
+func (c *conn) expect(cmd string, lines ...string) {
+	c.t.Helper()
+
+	if _, err := c.Write([]byte(cmd)); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for _, want := range lines {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if got := strings.TrimRight(line, "\r\n"); got != want {
+			c.t.Errorf("%q: expected %q, got %q", strings.TrimSpace(cmd), want, got)
+		}
+	}
+}
+
+func TestStorage(t *testing.T) {
+	c := createConn(t)
+
+	c.expect("get key\r\n", "END")
+	c.expect("replace key 0 0 5\r\nvalue\r\n", "NOT_STORED")
+	c.expect("add key 7 0 5\r\nvalue\r\n", "STORED")
+	c.expect("add key 0 0 5\r\nother\r\n", "NOT_STORED")
+	c.expect("get key missing\r\n", "VALUE key 7 5", "value", "END")
+	c.expect("replace key 0 0 5\r\nother\r\n", "STORED")
+	c.expect("set key 0 0 5 noreply\r\nthird\r\nget key\r\n", "VALUE key 0 5", "third", "END")
+	c.expect("set key 0 0 3\r\nvalue\r\n", "CLIENT_ERROR bad data chunk")
+	c.expect("delete key\r\n", "DELETED")
+	c.expect("delete key\r\n", "NOT_FOUND")
+	c.expect("unknown\r\n", "ERROR")
+}
+
+func TestCAS(t *testing.T) {
+	c := createConn(t)
+
+	c.expect("cas key 0 0 5 1\r\nvalue\r\n", "NOT_FOUND")
+	c.expect("set key 0 0 5\r\nvalue\r\n", "STORED")
+	c.expect("gets key\r\n", "VALUE key 0 5 1", "value", "END")
+	c.expect("cas key 0 0 5 2\r\nother\r\n", "EXISTS")
+	c.expect("cas key 0 0 5 1\r\nother\r\n", "STORED")
+	c.expect("gets key\r\n", "VALUE key 0 5 2", "other", "END")
+}
+
+func TestIncr(t *testing.T) {
+	c := createConn(t)
+
+	c.expect("incr n 1\r\n", "NOT_FOUND")
+	c.expect("set n 0 0 2\r\n10\r\n", "STORED")
+	c.expect("incr n 5\r\n", "15")
+	c.expect("decr n 20\r\n", "0")
+	c.expect("set s 0 0 1\r\na\r\n", "STORED")
+	c.expect("incr s 1\r\n", "CLIENT_ERROR cannot increment or decrement non-numeric value")
+}
+
+func TestExpiry(t *testing.T) {
+	c := createConn(t)
+
+	c.expect("touch key 0\r\n", "NOT_FOUND")
+	c.expect("set key 0 1 5\r\nvalue\r\n", "STORED")
+	c.expect("set other 0 1 5\r\nvalue\r\n", "STORED")
+	c.expect("touch other -1\r\n", "TOUCHED")
+	c.expect("get other\r\n", "END")
+	c.expect("touch key 0\r\n", "TOUCHED")
+	c.expect("set short 0 1 5\r\nvalue\r\n", "STORED")
+
+	c.expect("mg short t\r\n", "HD t1")
+	c.expect("mg key t v\r\n", "VA 5 t-1", "value")
+	c.expect("mg missing t\r\n", "EN")
+	c.expect("mg key k\r\n", "CLIENT_ERROR invalid flag")
+
+	time.Sleep(1100 * time.Millisecond)
+	c.expect("get key short\r\n", "VALUE key 0 5", "value", "END")
+	c.expect("lru_crawler metadump all\r\n", "key=key exp=-1 cas=4 size=5", "END")
+}
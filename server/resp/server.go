@@ -0,0 +1,684 @@
+package resp
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+	"github.com/go-zoox/kv/typing"
+)
+
+// Server exposes a KV over the redis protocol (RESP2 and RESP3), so that redis-cli
+// and redis clients can talk to it.
+//
+// Supported commands are GET, SET (EX, PX, NX, XX, KEEPTTL, GET), DEL, EXISTS, KEYS, SCAN,
+// TTL, PTTL, EXPIRE, PEXPIRE, PERSIST, FLUSHDB, DBSIZE, and the connection commands
+// PING, ECHO, HELLO, AUTH, SELECT 0 and QUIT.
+//
+// The values are stored as strings, so that the other clients of the KV read them as is,
+// and the values they write, which are not strings, are read as their JSON.
+// TTL and PTTL answer -1 for all keys, unless the KV implements typing.TTL.
+type Server struct {
+	sync.Mutex
+	KV     typing.KV
+	Config *Config
+}
+
+// Config is the configuration for Server.
+type Config struct {
+	// Password is the password required by AUTH, empty disables auth.
+	Password string
+}
+
+// conn is the state of a client connection.
+type conn struct {
+	*writer
+	authorized bool
+}
+
+// New returns a new Server.
+func New(kv typing.KV, cfg ...*Config) *Server {
+	cfgX := &Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		cfgX = cfg[0]
+	}
+
+	return &Server{
+		KV:     kv,
+		Config: cfgX,
+	}
+}
+
+// ListenAndServe listens on the TCP address addr, and serves the connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l, and serves each one in a goroutine.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		go s.ServeConn(c)
+	}
+}
+
+// ServeConn serves commands on a connection, until the client quits or closes it.
+func (s *Server) ServeConn(nc net.Conn) {
+	defer nc.Close()
+
+	r := bufio.NewReader(nc)
+	c := &conn{
+		writer:     &writer{Writer: bufio.NewWriter(nc), proto: 2},
+		authorized: s.Config.Password == "",
+	}
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err == errProtocol {
+				c.error(err.Error())
+				c.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.exec(c, args)
+
+		// flush once the pipelined commands are all handled
+		if r.Buffered() == 0 || quit {
+			if err := c.Flush(); err != nil {
+				return
+			}
+		}
+		if quit {
+			return
+		}
+	}
+}
+
+func (s *Server) checkPassword(password string) bool {
+	return subtle.ConstantTimeCompare([]byte(password), []byte(s.Config.Password)) == 1
+}
+
+// exec executes a command, and returns true if the connection should be closed.
+func (s *Server) exec(c *conn, args []string) bool {
+	cmd := strings.ToUpper(args[0])
+	args = args[1:]
+
+	switch cmd {
+	case "QUIT":
+		c.simple("OK")
+		return true
+	case "AUTH":
+		s.auth(c, args)
+		return false
+	case "HELLO":
+		s.hello(c, args)
+		return false
+	}
+
+	if !c.authorized {
+		c.error("NOAUTH Authentication required.")
+		return false
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	switch cmd {
+	case "PING":
+		if len(args) > 0 {
+			c.bulk(args[0])
+		} else {
+			c.simple("PONG")
+		}
+	case "ECHO":
+		if len(args) != 1 {
+			c.error("ERR wrong number of arguments for 'echo' command")
+			return false
+		}
+		c.bulk(args[0])
+	case "SELECT":
+		if len(args) != 1 {
+			c.error("ERR wrong number of arguments for 'select' command")
+		} else if args[0] != "0" {
+			c.error("ERR DB index is out of range")
+		} else {
+			c.simple("OK")
+		}
+	case "COMMAND":
+		c.array(0)
+	case "CLIENT":
+		c.simple("OK")
+	case "GET":
+		s.get(c, args)
+	case "SET":
+		s.set(c, args)
+	case "DEL", "UNLINK":
+		s.del(c, args)
+	case "EXISTS":
+		s.exists(c, args)
+	case "KEYS":
+		s.keys(c, args)
+	case "SCAN":
+		s.scan(c, args)
+	case "TTL", "PTTL":
+		s.ttl(c, args, cmd == "PTTL")
+	case "EXPIRE", "PEXPIRE":
+		s.expire(c, args, cmd == "PEXPIRE")
+	case "PERSIST":
+		s.persist(c, args)
+	case "FLUSHDB", "FLUSHALL":
+		if err := s.KV.Clear(); err != nil {
+			c.error("ERR " + err.Error())
+			return false
+		}
+		c.simple("OK")
+	case "DBSIZE":
+		c.integer(int64(s.KV.Size()))
+	default:
+		c.error("ERR unknown command '" + strings.ToLower(cmd) + "'")
+	}
+
+	return false
+}
+
+func (s *Server) auth(c *conn, args []string) {
+	var password string
+	switch len(args) {
+	case 1:
+		password = args[0]
+	case 2:
+		password = args[1]
+	default:
+		c.error("ERR wrong number of arguments for 'auth' command")
+		return
+	}
+
+	if s.Config.Password == "" {
+		c.error("ERR AUTH <password> called without any password configured for the default user.")
+		return
+	}
+	if !s.checkPassword(password) {
+		c.error("WRONGPASS invalid username-password pair or user is disabled.")
+		return
+	}
+
+	c.authorized = true
+	c.simple("OK")
+}
+
+func (s *Server) hello(c *conn, args []string) {
+	proto := c.proto
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			c.error("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if n != 2 && n != 3 {
+			c.error("NOPROTO unsupported protocol version")
+			return
+		}
+		proto = n
+		args = args[1:]
+	}
+
+	for len(args) > 0 {
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			if len(args) < 3 {
+				c.error("ERR syntax error")
+				return
+			}
+			if !s.checkPassword(args[2]) {
+				c.error("WRONGPASS invalid username-password pair or user is disabled.")
+				return
+			}
+			c.authorized = true
+			args = args[3:]
+		case "SETNAME":
+			if len(args) < 2 {
+				c.error("ERR syntax error")
+				return
+			}
+			args = args[2:]
+		default:
+			c.error("ERR syntax error")
+			return
+		}
+	}
+
+	if !c.authorized {
+		c.error("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+		return
+	}
+
+	c.proto = proto
+	c.dict(7)
+	c.bulk("server")
+	c.bulk("redis")
+	c.bulk("version")
+	c.bulk("7.0.0")
+	c.bulk("proto")
+	c.integer(int64(proto))
+	c.bulk("id")
+	c.integer(1)
+	c.bulk("mode")
+	c.bulk("standalone")
+	c.bulk("role")
+	c.bulk("master")
+	c.bulk("modules")
+	c.array(0)
+}
+
+// load returns the value of the key, or false if it does not exist.
+func (s *Server) load(key string) (any, bool) {
+	if !s.KV.Has(key) {
+		return nil, false
+	}
+
+	var value any
+	if err := s.KV.Get(key, &value); err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// text returns the value as a string, or its JSON if it was not written as a string.
+func text(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	raw, _ := json.Marshal(value)
+	return string(raw)
+}
+
+// ref returns a pointer of the loaded value, to store it again,
+// as the engines, such as memory, expect a pointer.
+func ref(value any) any {
+	if str, ok := value.(string); ok {
+		return &str
+	}
+
+	return &value
+}
+
+// remaining returns the remaining time to live of the key, or -1 if it never expires
+// or the KV does not implement typing.TTL, and false if the key does not exist.
+func (s *Server) remaining(key string) (time.Duration, bool, error) {
+	ttl, ok := s.KV.(typing.TTL)
+	if !ok {
+		return -1, s.KV.Has(key), nil
+	}
+
+	d, err := ttl.TTL(key)
+	if errors.Is(err, typing.ErrNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if d < 0 {
+		d = -1
+	}
+
+	return d, true, nil
+}
+
+func (s *Server) get(c *conn, args []string) {
+	if len(args) != 1 {
+		c.error("ERR wrong number of arguments for 'get' command")
+		return
+	}
+
+	value, ok := s.load(args[0])
+	if !ok {
+		c.null()
+		return
+	}
+
+	c.bulk(text(value))
+}
+
+func (s *Server) set(c *conn, args []string) {
+	if len(args) < 2 {
+		c.error("ERR wrong number of arguments for 'set' command")
+		return
+	}
+
+	key, value := args[0], args[1]
+	var maxAge time.Duration
+	var nx, xx, keepTTL, get, hasExpiry bool
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "KEEPTTL":
+			keepTTL = true
+		case "GET":
+			get = true
+		case "EX", "PX":
+			if hasExpiry || i+1 >= len(args) {
+				c.error("ERR syntax error")
+				return
+			}
+
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				c.error("ERR value is not an integer or out of range")
+				return
+			}
+			if n <= 0 {
+				c.error("ERR invalid expire time in 'set' command")
+				return
+			}
+
+			maxAge = time.Duration(n) * time.Millisecond
+			if opt == "EX" {
+				maxAge = time.Duration(n) * time.Second
+			}
+			hasExpiry = true
+			i++
+		default:
+			c.error("ERR syntax error")
+			return
+		}
+	}
+	if (nx && xx) || (keepTTL && hasExpiry) {
+		c.error("ERR syntax error")
+		return
+	}
+
+	old, exists := s.load(key)
+	if (nx && exists) || (xx && !exists) {
+		if get && exists {
+			c.bulk(text(old))
+		} else {
+			c.null()
+		}
+		return
+	}
+
+	var err error
+	switch {
+	case hasExpiry:
+		err = s.KV.Set(key, &value, maxAge)
+	case keepTTL:
+		err = s.KV.Set(key, &value)
+	default:
+		// redis SET discards the origin expiry
+		err = typing.Replace(s.KV, key, &value)
+	}
+	if err != nil {
+		c.error("ERR " + err.Error())
+		return
+	}
+
+	if get {
+		if exists {
+			c.bulk(text(old))
+		} else {
+			c.null()
+		}
+		return
+	}
+
+	c.simple("OK")
+}
+
+func (s *Server) del(c *conn, args []string) {
+	if len(args) == 0 {
+		c.error("ERR wrong number of arguments for 'del' command")
+		return
+	}
+
+	n := int64(0)
+	for _, key := range args {
+		if !s.KV.Has(key) {
+			continue
+		}
+
+		if err := s.KV.Delete(key); err != nil {
+			c.error("ERR " + err.Error())
+			return
+		}
+		n++
+	}
+
+	c.integer(n)
+}
+
+func (s *Server) exists(c *conn, args []string) {
+	if len(args) == 0 {
+		c.error("ERR wrong number of arguments for 'exists' command")
+		return
+	}
+
+	n := int64(0)
+	for _, key := range args {
+		if s.KV.Has(key) {
+			n++
+		}
+	}
+
+	c.integer(n)
+}
+
+// sortedKeys returns the keys matching the pattern, in order,
+// so that SCAN cursors stay stable between calls.
+// The KV matches the pattern natively if it implements typing.Glob.
+func (s *Server) sortedKeys(pattern string) []string {
+	var keys []string
+	if g, ok := s.KV.(typing.Glob); ok {
+		keys = g.KeysMatching(pattern)
+	} else {
+		keys = []string{}
+		for _, key := range s.KV.Keys() {
+			if glob.Match(pattern, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (s *Server) keys(c *conn, args []string) {
+	if len(args) != 1 {
+		c.error("ERR wrong number of arguments for 'keys' command")
+		return
+	}
+
+	c.bulks(s.sortedKeys(args[0]))
+}
+
+func (s *Server) scan(c *conn, args []string) {
+	if len(args) == 0 {
+		c.error("ERR wrong number of arguments for 'scan' command")
+		return
+	}
+
+	cursor, err := strconv.Atoi(args[0])
+	if err != nil || cursor < 0 {
+		c.error("ERR invalid cursor")
+		return
+	}
+
+	pattern := "*"
+	count := 10
+	for i := 1; i < len(args); i++ {
+		if i+1 >= len(args) {
+			c.error("ERR syntax error")
+			return
+		}
+
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			count, err = strconv.Atoi(args[i+1])
+			if err != nil || count < 1 {
+				c.error("ERR syntax error")
+				return
+			}
+		case "TYPE":
+			// all values are strings
+			if strings.ToLower(args[i+1]) != "string" {
+				pattern = ""
+			}
+		default:
+			c.error("ERR syntax error")
+			return
+		}
+		i++
+	}
+
+	all := s.sortedKeys("*")
+	if cursor > len(all) {
+		cursor = len(all)
+	}
+
+	end := cursor + count
+	next := end
+	if end >= len(all) {
+		end = len(all)
+		next = 0
+	}
+
+	keys := []string{}
+	for _, key := range all[cursor:end] {
+		if pattern != "" && glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	c.array(2)
+	c.bulk(strconv.Itoa(next))
+	c.bulks(keys)
+}
+
+func (s *Server) ttl(c *conn, args []string, ms bool) {
+	if len(args) != 1 {
+		c.error("ERR wrong number of arguments for 'ttl' command")
+		return
+	}
+
+	d, exists, err := s.remaining(args[0])
+	if err != nil {
+		c.error("ERR " + err.Error())
+		return
+	}
+	if !exists {
+		c.integer(-2)
+		return
+	}
+	if d < 0 {
+		c.integer(-1)
+		return
+	}
+
+	if ms {
+		c.integer(d.Milliseconds())
+		return
+	}
+
+	c.integer(int64((d + 500*time.Millisecond) / time.Second))
+}
+
+func (s *Server) expire(c *conn, args []string, ms bool) {
+	if len(args) != 2 {
+		c.error("ERR wrong number of arguments for 'expire' command")
+		return
+	}
+
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		c.error("ERR value is not an integer or out of range")
+		return
+	}
+	maxAge := time.Duration(n) * time.Millisecond
+	if !ms {
+		maxAge = time.Duration(n) * time.Second
+	}
+
+	key := args[0]
+	value, ok := s.load(key)
+	if !ok {
+		c.integer(0)
+		return
+	}
+
+	if maxAge <= 0 {
+		err = s.KV.Delete(key)
+	} else {
+		err = s.KV.Set(key, ref(value), maxAge)
+	}
+	if err != nil {
+		c.error("ERR " + err.Error())
+		return
+	}
+
+	c.integer(1)
+}
+
+func (s *Server) persist(c *conn, args []string) {
+	if len(args) != 1 {
+		c.error("ERR wrong number of arguments for 'persist' command")
+		return
+	}
+
+	key := args[0]
+	d, exists, err := s.remaining(key)
+	if err != nil {
+		c.error("ERR " + err.Error())
+		return
+	}
+	if !exists || d < 0 {
+		c.integer(0)
+		return
+	}
+
+	value, ok := s.load(key)
+	if !ok {
+		c.integer(0)
+		return
+	}
+
+	if err := typing.Replace(s.KV, key, ref(value)); err != nil {
+		c.error("ERR " + err.Error())
+		return
+	}
+
+	c.integer(1)
+}
@@ -0,0 +1,193 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/typing"
+)
+
+func createServer(t *testing.T, cfg ...*Config) string {
+	return serve(t, memory.New(), cfg...)
+}
+
+func serve(t *testing.T, kv typing.KV, cfg ...*Config) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go New(kv, cfg...).Serve(l)
+	return l.Addr().String()
+}
+
+func TestCommands(t *testing.T) {
+	ctx := context.Background()
+	client := goredis.NewClient(&goredis.Options{Addr: createServer(t)})
+	defer client.Close()
+
+	if err := client.Set(ctx, "key", "value", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if v := client.Get(ctx, "key").Val(); v != "value" {
+		t.Errorf("Expected value, got %s", v)
+	}
+	if err := client.Get(ctx, "missing").Err(); err != goredis.Nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if ok := client.SetNX(ctx, "key", "other", 0).Val(); ok {
+		t.Error("Expected SET NX to fail on existing key")
+	}
+	if ok := client.SetXX(ctx, "missing", "other", 0).Val(); ok {
+		t.Error("Expected SET XX to fail on missing key")
+	}
+
+	client.Set(ctx, "ttl", "value", 10*time.Second)
+	if ttl := client.TTL(ctx, "ttl").Val(); ttl != 10*time.Second {
+		t.Errorf("Expected ttl 10s, got %s", ttl)
+	}
+	client.Set(ctx, "ttl", "value2", goredis.KeepTTL)
+	if ttl := client.TTL(ctx, "ttl").Val(); ttl <= 0 {
+		t.Errorf("Expected ttl kept, got %s", ttl)
+	}
+	client.Set(ctx, "ttl", "value3", 0)
+	if ttl := client.TTL(ctx, "ttl").Val(); ttl != -1 {
+		t.Errorf("Expected ttl discarded by SET, got %s", ttl)
+	}
+	if ok := client.PExpire(ctx, "ttl", 50*time.Millisecond).Val(); !ok {
+		t.Error("Expected PEXPIRE to succeed")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := client.Exists(ctx, "ttl").Val(); n != 0 {
+		t.Errorf("Expected key to expire, got exists %d", n)
+	}
+
+	client.Set(ctx, "user:1", "a", 0)
+	client.Set(ctx, "user:2", "b", 0)
+	if keys := client.Keys(ctx, "user:*").Val(); len(keys) != 2 {
+		t.Errorf("Expected 2 keys, got %v", keys)
+	}
+
+	var scanned []string
+	iter := client.Scan(ctx, 0, "user:?", 1).Iterator()
+	for iter.Next(ctx) {
+		scanned = append(scanned, iter.Val())
+	}
+	if len(scanned) != 2 {
+		t.Errorf("Expected 2 scanned keys, got %v", scanned)
+	}
+
+	if n := client.DBSize(ctx).Val(); n != 3 {
+		t.Errorf("Expected dbsize 3, got %d", n)
+	}
+	if n := client.Del(ctx, "user:1", "missing").Val(); n != 1 {
+		t.Errorf("Expected 1 deleted, got %d", n)
+	}
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.DBSize(ctx).Val(); n != 0 {
+		t.Errorf("Expected dbsize 0, got %d", n)
+	}
+}
+
+func TestSharedKV(t *testing.T) {
+	ctx := context.Background()
+	kv := memory.New()
+	client := goredis.NewClient(&goredis.Options{Addr: serve(t, kv)})
+	defer client.Close()
+
+	client.Set(ctx, "key", "value", 10*time.Second)
+	var value string
+	if err := kv.Get("key", &value); err != nil {
+		t.Fatal(err)
+	}
+	if value != "value" {
+		t.Errorf("Expected value, got %s", value)
+	}
+
+	kv.Set("native", &value, 5*time.Second)
+	if v := client.Get(ctx, "native").Val(); v != "value" {
+		t.Errorf("Expected value, got %s", v)
+	}
+	if ttl := client.TTL(ctx, "native").Val(); ttl != 5*time.Second {
+		t.Errorf("Expected ttl 5s, got %s", ttl)
+	}
+	if ok := client.Persist(ctx, "native").Val(); !ok {
+		t.Error("Expected PERSIST to succeed")
+	}
+	if ttl, _ := kv.TTL("native"); ttl >= 0 {
+		t.Errorf("Expected no expiry, got %s", ttl)
+	}
+
+	n := 42
+	kv.Set("number", &n)
+	if v := client.Get(ctx, "number").Val(); v != "42" {
+		t.Errorf("Expected 42, got %s", v)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	ctx := context.Background()
+	addr := createServer(t, &Config{Password: "secret"})
+
+	client := goredis.NewClient(&goredis.Options{Addr: addr})
+	defer client.Close()
+	if err := client.Ping(ctx).Err(); err == nil {
+		t.Error("Expected NOAUTH error")
+	}
+
+	client = goredis.NewClient(&goredis.Options{Addr: addr, Password: "secret"})
+	defer client.Close()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRESP3(t *testing.T) {
+	conn, err := net.Dial("tcp", createServer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	r := bufio.NewReader(conn)
+	conn.Write([]byte("*2\r\n$5\r\nHELLO\r\n$1\r\n3\r\n"))
+	if line, _ := r.ReadString('\n'); line != "%7\r\n" {
+		t.Fatalf("Expected RESP3 map, got %q", line)
+	}
+	// 7 bulk keys, 4 bulk values, 2 integers and 1 empty array
+	for i := 0; i < 7*2+4*2+2+1; i++ {
+		r.ReadString('\n')
+	}
+
+	// inline command
+	conn.Write([]byte("GET missing\r\n"))
+	if line, _ := r.ReadString('\n'); line != "_\r\n" {
+		t.Errorf("Expected RESP3 null, got %q", line)
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	for _, request := range []string{"*-1\r\n", "*1\r\n$-5\r\n"} {
+		conn, err := net.Dial("tcp", createServer(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		conn.Write([]byte(request))
+		if line, _ := bufio.NewReader(conn).ReadString('\n'); line != "-ERR Protocol error\r\n" {
+			t.Errorf("Expected protocol error for %q, got %q", request, line)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,68 @@
+package shard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-zoox/kv"
+	"github.com/go-zoox/kv/typing"
+)
+
+// Rebalance moves the keys which are not in their backend, after Add or Remove,
+// from the backends and the removed ones to their backend, and returns the number of moved keys.
+//
+// The time to live is kept if the source backend implements typing.TTL.
+// The keys written during Rebalance could be overwritten by their old value.
+func (s *Shard) Rebalance(removed ...typing.KV) (int, error) {
+	type source struct {
+		name string
+		kv   typing.KV
+	}
+
+	var sources []source
+	for name, kv := range s.Backends() {
+		sources = append(sources, source{name, kv})
+	}
+	for i, kv := range removed {
+		sources = append(sources, source{fmt.Sprintf("removed[%d]", i), kv})
+	}
+
+	moved := 0
+	var errs []error
+	for _, src := range sources {
+		n, err := s.move(src.name, src.kv)
+		moved += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src.name, err))
+		}
+	}
+
+	return moved, errors.Join(errs...)
+}
+
+// move moves the keys of the backend named name, which are routed to another backend.
+func (s *Shard) move(name string, src typing.KV) (int, error) {
+	moved := 0
+	for _, key := range src.Keys() {
+		if owner, _ := s.Backend(key); owner == name {
+			continue
+		}
+
+		copied, err := kv.Copy(src, s.backend(key), key)
+		if err != nil {
+			return moved, err
+		}
+		if !copied {
+			// expired since listed
+			continue
+		}
+
+		if err := src.Delete(key); err != nil {
+			return moved, err
+		}
+
+		moved++
+	}
+
+	return moved, nil
+}
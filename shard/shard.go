@@ -0,0 +1,200 @@
+package shard
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/dgryski/go-rendezvous"
+	"github.com/go-zoox/kv/typing"
+)
+
+// Shard is a Key-Value Store spread over several backends,
+// each key is routed to a backend by rendezvous hashing of the backend names.
+// Keys, Size, ForEach and Clear fan out to all backends.
+type Shard struct {
+	sync.RWMutex
+
+	backends map[string]typing.KV
+	ring     *rendezvous.Rendezvous
+}
+
+// New returns a new Shard over the backends, by name.
+// The names, not the order, decide where the keys go, so they must be stable.
+func New(backends map[string]typing.KV) (*Shard, error) {
+	if len(backends) == 0 {
+		return nil, errors.New("shard backends are required")
+	}
+
+	s := &Shard{
+		backends: make(map[string]typing.KV, len(backends)),
+	}
+	for name, kv := range backends {
+		s.backends[name] = kv
+	}
+
+	s.build()
+	return s, nil
+}
+
+func (s *Shard) build() {
+	names := make([]string, 0, len(s.backends))
+	for name := range s.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.ring = rendezvous.New(names, xxhash.Sum64String)
+}
+
+// Add adds a backend, the keys are not moved to it until Rebalance.
+func (s *Shard) Add(name string, kv typing.KV) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.backends[name] = kv
+	s.build()
+}
+
+// Remove removes a backend and returns it, to be passed to Rebalance.
+func (s *Shard) Remove(name string) (typing.KV, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	kv, ok := s.backends[name]
+	if !ok || len(s.backends) == 1 {
+		return nil, false
+	}
+
+	delete(s.backends, name)
+	s.build()
+	return kv, true
+}
+
+// Backend returns the name and the backend of the key.
+func (s *Shard) Backend(key string) (string, typing.KV) {
+	s.RLock()
+	defer s.RUnlock()
+
+	name := s.ring.Lookup(key)
+	return name, s.backends[name]
+}
+
+// Backends returns the backends, by name.
+func (s *Shard) Backends() map[string]typing.KV {
+	s.RLock()
+	defer s.RUnlock()
+
+	backends := make(map[string]typing.KV, len(s.backends))
+	for name, kv := range s.backends {
+		backends[name] = kv
+	}
+
+	return backends
+}
+
+func (s *Shard) backend(key string) typing.KV {
+	_, kv := s.Backend(key)
+	return kv
+}
+
+// fanOut calls fn for each backend concurrently.
+func (s *Shard) fanOut(fn func(name string, kv typing.KV) error) error {
+	backends := s.Backends()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for name, kv := range backends {
+		wg.Add(1)
+		go func(name string, kv typing.KV) {
+			defer wg.Done()
+
+			if err := fn(name, kv); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				mu.Unlock()
+			}
+		}(name, kv)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Set sets the value for the given key, in its backend.
+func (s *Shard) Set(key string, value any, maxAge ...time.Duration) error {
+	return s.backend(key).Set(key, value, maxAge...)
+}
+
+// Get returns the value for the given key, from its backend.
+func (s *Shard) Get(key string, value any) error {
+	return s.backend(key).Get(key, value)
+}
+
+// Delete deletes the value for the given key, in its backend.
+func (s *Shard) Delete(key string) error {
+	return s.backend(key).Delete(key)
+}
+
+// Has returns true if the given key exists in its backend.
+func (s *Shard) Has(key string) bool {
+	return s.backend(key).Has(key)
+}
+
+// Keys returns the keys of all backends.
+func (s *Shard) Keys() []string {
+	var mu sync.Mutex
+	keys := []string{}
+	s.fanOut(func(name string, kv typing.KV) error {
+		k := kv.Keys()
+
+		mu.Lock()
+		keys = append(keys, k...)
+		mu.Unlock()
+		return nil
+	})
+
+	return keys
+}
+
+// Size returns the number of elements in all backends.
+func (s *Shard) Size() int {
+	var mu sync.Mutex
+	size := 0
+	s.fanOut(func(name string, kv typing.KV) error {
+		n := kv.Size()
+
+		mu.Lock()
+		size += n
+		mu.Unlock()
+		return nil
+	})
+
+	return size
+}
+
+// Clear removes all elements from all backends.
+func (s *Shard) Clear() error {
+	return s.fanOut(func(name string, kv typing.KV) error {
+		return kv.Clear()
+	})
+}
+
+// ForEach calls the given function for each key-value pair in all backends, one backend at a time.
+func (s *Shard) ForEach(f func(string, interface{})) {
+	backends := s.Backends()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		backends[name].ForEach(f)
+	}
+}
@@ -0,0 +1,146 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/fs"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/test"
+	"github.com/go-zoox/kv/typing"
+)
+
+func createShard(t *testing.T, n int) *Shard {
+	backends := map[string]typing.KV{}
+	for i := 0; i < n; i++ {
+		backends[fmt.Sprintf("node-%d", i)] = memory.New()
+	}
+
+	s, err := New(backends)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestKV(t *testing.T) {
+	test.RunTestCases(t, createShard(t, 3))
+}
+
+func TestFileSystem(t *testing.T) {
+	a, _ := fs.New(&fs.FileSystemOptions{Dir: t.TempDir()})
+	b, _ := fs.New(&fs.FileSystemOptions{Dir: t.TempDir()})
+	s, err := New(map[string]typing.KV{"a": a, "b": b})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.RunTestCases(t, s)
+}
+
+func TestDistribution(t *testing.T) {
+	s := createShard(t, 3)
+	for i := 0; i < 300; i++ {
+		value := i
+		s.Set(fmt.Sprintf("key%d", i), &value)
+	}
+
+	if s.Size() != 300 {
+		t.Errorf("Expected size 300, got %d", s.Size())
+	}
+	for name, kv := range s.Backends() {
+		if kv.Size() < 50 {
+			t.Errorf("Expected keys spread evenly, %s has %d", name, kv.Size())
+		}
+	}
+
+	name, kv := s.Backend("key1")
+	if !kv.Has("key1") {
+		t.Errorf("Expected key1 in %s", name)
+	}
+
+	// the order of the backends does not matter
+	other, _ := New(s.Backends())
+	if n, _ := other.Backend("key1"); n != name {
+		t.Errorf("Expected the same backend, got %s and %s", name, n)
+	}
+
+	if _, err := New(nil); err == nil {
+		t.Error("Expected no backends to be an error")
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	s := createShard(t, 2)
+	for i := 0; i < 100; i++ {
+		value := fmt.Sprintf("value%d", i)
+		s.Set(fmt.Sprintf("key%d", i), &value)
+	}
+	ttlValue := "ttl"
+	s.Set("ttl", &ttlValue, time.Hour)
+
+	s.Add("node-2", memory.New())
+	moved, err := s.Rebalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved == 0 || moved > 60 {
+		t.Errorf("Expected about a third of the keys moved, got %d", moved)
+	}
+
+	removed, ok := s.Remove("node-0")
+	if !ok {
+		t.Fatal("Expected node-0 to be removed")
+	}
+	if _, err := s.Rebalance(removed); err != nil {
+		t.Fatal(err)
+	}
+	if removed.Size() != 0 {
+		t.Errorf("Expected removed backend to be drained, got %d", removed.Size())
+	}
+
+	if s.Size() != 101 {
+		t.Errorf("Expected size 101, got %d", s.Size())
+	}
+	for i := 0; i < 100; i++ {
+		var value string
+		if err := s.Get(fmt.Sprintf("key%d", i), &value); err != nil || value != fmt.Sprintf("value%d", i) {
+			t.Errorf("Expected value%d, got %q, %v", i, value, err)
+		}
+	}
+
+	_, kv := s.Backend("ttl")
+	if d, err := kv.(typing.TTL).TTL("ttl"); err != nil || d <= 0 {
+		t.Errorf("Expected ttl kept, got %s, %v", d, err)
+	}
+}
+
+func TestRebalanceFromFileSystem(t *testing.T) {
+	s := createShard(t, 2)
+
+	removed, _ := fs.New(&fs.FileSystemOptions{Dir: t.TempDir()})
+	removed.Set("null", nil)
+	for i := 0; i < 10; i++ {
+		removed.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+
+	moved, err := s.Rebalance(removed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 11 || removed.Size() != 0 {
+		t.Errorf("Expected 11 keys moved, got %d, %d left", moved, removed.Size())
+	}
+
+	if !s.Has("null") {
+		t.Error("Expected null value moved")
+	}
+	for i := 0; i < 10; i++ {
+		var value string
+		if err := s.Get(fmt.Sprintf("key%d", i), &value); err != nil || value != fmt.Sprintf("value%d", i) {
+			t.Errorf("Expected value%d, got %q, %v", i, value, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect adapts the queries of the engine to a database.
+type Dialect interface {
+	// Name returns the name of the dialect.
+	Name() string
+	// Placeholder returns the placeholder of the n-th (1-based) query argument.
+	Placeholder(n int) string
+	// Migrate returns the statements to create the table and its expiry index.
+	Migrate(table string) []string
+	// Upsert returns the query to insert or update an entry,
+	// with arguments (id, value, expiresAt, now).
+	// If keepTTL is true, the origin expiresAt is kept unless it has expired at now.
+	Upsert(table string, keepTTL bool) string
+}
+
+// Postgres is the dialect for PostgreSQL.
+var Postgres Dialect = &postgres{}
+
+// MySQL is the dialect for MySQL.
+var MySQL Dialect = &mysql{}
+
+// SQLite is the dialect for SQLite.
+var SQLite Dialect = &sqlite{}
+
+// dialects maps database/sql driver names to dialects.
+var dialects = map[string]Dialect{
+	"postgres": Postgres,
+	"pgx":      Postgres,
+	"mysql":    MySQL,
+	"sqlite":   SQLite,
+	"sqlite3":  SQLite,
+}
+
+// GetDialect returns the dialect for the given dialect or driver name.
+func GetDialect(name string) (Dialect, error) {
+	if d, ok := dialects[strings.ToLower(name)]; ok {
+		return d, nil
+	}
+
+	return nil, fmt.Errorf("unsupported sql dialect: %s", name)
+}
+
+type postgres struct{}
+
+func (d *postgres) Name() string {
+	return "postgres"
+}
+
+func (d *postgres) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (d *postgres) Migrate(table string) []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			value BYTEA NOT NULL,
+			expires_at BIGINT NOT NULL DEFAULT 0
+		)`, table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_expires_at ON %[1]s (expires_at)`, table),
+	}
+}
+
+func (d *postgres) Upsert(table string, keepTTL bool) string {
+	expiresAt := "EXCLUDED.expires_at"
+	if keepTTL {
+		expiresAt = fmt.Sprintf("CASE WHEN %[1]s.expires_at >= $4 THEN %[1]s.expires_at ELSE 0 END", table)
+	}
+
+	return fmt.Sprintf(`INSERT INTO %s (id, value, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET value = EXCLUDED.value, expires_at = %s`, table, expiresAt)
+}
+
+type mysql struct{}
+
+func (d *mysql) Name() string {
+	return "mysql"
+}
+
+func (d *mysql) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *mysql) Migrate(table string) []string {
+	// the binary collation keeps keys case sensitive
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+			id VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL PRIMARY KEY,
+			value LONGBLOB NOT NULL,
+			expires_at BIGINT NOT NULL DEFAULT 0,
+			INDEX %[1]s_expires_at (expires_at)
+		)`, table),
+	}
+}
+
+func (d *mysql) Upsert(table string, keepTTL bool) string {
+	if keepTTL {
+		return fmt.Sprintf(`INSERT INTO %s (id, value, expires_at) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE expires_at = IF(expires_at >= ?, expires_at, 0), value = VALUES(value)`, table)
+	}
+
+	return fmt.Sprintf(`INSERT INTO %s (id, value, expires_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE value = VALUES(value), expires_at = VALUES(expires_at)`, table)
+}
+
+type sqlite struct{}
+
+func (d *sqlite) Name() string {
+	return "sqlite"
+}
+
+func (d *sqlite) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *sqlite) Migrate(table string) []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			value BLOB NOT NULL,
+			expires_at INTEGER NOT NULL DEFAULT 0
+		)`, table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_expires_at ON %[1]s (expires_at)`, table),
+	}
+}
+
+func (d *sqlite) Upsert(table string, keepTTL bool) string {
+	expiresAt := "excluded.expires_at"
+	if keepTTL {
+		expiresAt = fmt.Sprintf("CASE WHEN %[1]s.expires_at >= ?4 THEN %[1]s.expires_at ELSE 0 END", table)
+	}
+
+	return fmt.Sprintf(`INSERT INTO %s (id, value, expires_at) VALUES (?1, ?2, ?3)
+		ON CONFLICT (id) DO UPDATE SET value = excluded.value, expires_at = %s`, table, expiresAt)
+}
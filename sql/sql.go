@@ -0,0 +1,354 @@
+package sql
+
+import (
+	gosql "database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+)
+
+// SQL is a Key-Value Store in a database/sql database,
+// such as PostgreSQL, MySQL and SQLite.
+type SQL struct {
+	Core    *gosql.DB
+	Config  *Config
+	Dialect Dialect
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// Config is the configuration for SQL.
+type Config struct {
+	// Driver is the database/sql driver name, such as postgres, pgx, mysql or sqlite.
+	// The driver must be imported by the caller.
+	Driver string
+	// DSN is the data source name passed to the driver.
+	DSN string
+
+	// DB is an opened database, used instead of Driver and DSN.
+	DB *gosql.DB
+
+	// Dialect is the dialect name, postgres, mysql or sqlite.
+	// Default is detected from Driver.
+	Dialect string
+
+	// Table is the table to store the entries, default is kv.
+	Table string
+
+	// Prefix is the prefix to use for all keys.
+	Prefix string
+
+	// PurgeInterval is the interval to delete expired entries.
+	// Zero disables the periodic purge.
+	PurgeInterval time.Duration
+}
+
+// New returns a new SQL.
+func New(cfg *Config) (*SQL, error) {
+	if cfg.DB == nil && (cfg.Driver == "" || cfg.DSN == "") {
+		return nil, errors.New("sql DB or Driver and DSN are required")
+	}
+
+	dialectName := cfg.Dialect
+	if dialectName == "" {
+		dialectName = cfg.Driver
+	}
+	dialect, err := GetDialect(dialectName)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Table == "" {
+		cfg.Table = "kv"
+	}
+
+	core := cfg.DB
+	if core == nil {
+		core, err = gosql.Open(cfg.Driver, cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m := &SQL{
+		Core:    core,
+		Config:  cfg,
+		Dialect: dialect,
+		done:    make(chan struct{}),
+	}
+
+	if err := m.migrate(); err != nil {
+		if cfg.DB == nil {
+			core.Close()
+		}
+		return nil, err
+	}
+
+	if cfg.PurgeInterval > 0 {
+		m.wg.Add(1)
+		go m.runPurge()
+	}
+
+	return m, nil
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+func (m *SQL) migrate() error {
+	for _, stmt := range m.Dialect.Migrate(m.Config.Table) {
+		if _, err := m.Core.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *SQL) getKey(key string) string {
+	return m.Config.Prefix + key
+}
+
+// query replaces the ? placeholders with the dialect placeholders.
+func (m *SQL) query(format string) string {
+	format = fmt.Sprintf(format, m.Config.Table)
+
+	var b strings.Builder
+	n := 0
+	for _, c := range format {
+		if c == '?' {
+			n++
+			b.WriteString(m.Dialect.Placeholder(n))
+			continue
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+// prefixPattern returns a LIKE pattern matching all keys with the configured prefix and the given one,
+// escaped with !, which needs no quoting in any dialect.
+func (m *SQL) prefixPattern(prefix string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(m.getKey(prefix)) + "%"
+}
+
+// prefixCondition returns the condition on id, and its arguments, matching all keys
+// with the configured prefix and the given one.
+// SQLite LIKE ignores the ASCII case, so it is a byte-wise range there.
+func (m *SQL) prefixCondition(prefix string) (string, []any) {
+	if m.Dialect.Name() != SQLite.Name() {
+		return "id LIKE ? ESCAPE '!'", []any{m.prefixPattern(prefix)}
+	}
+
+	from := m.getKey(prefix)
+	if to, ok := upperBound(from); ok {
+		return "id >= ? AND id < ?", []any{from, to}
+	}
+
+	return "id >= ?", []any{from}
+}
+
+// upperBound returns the least string greater than all strings with the prefix,
+// or false if there is none, when the prefix is empty or all 0xff.
+func upperBound(prefix string) (string, bool) {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1]), true
+		}
+	}
+
+	return "", false
+}
+
+// Set sets the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge miliseconds.
+func (m *SQL) Set(key string, value any, maxAge ...time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if len(maxAge) > 0 {
+		expiresAt := now() + int64(maxAge[0]/time.Millisecond)
+		_, err = m.Core.Exec(m.Dialect.Upsert(m.Config.Table, false), m.getKey(key), raw, expiresAt)
+		return err
+	}
+
+	_, err = m.Core.Exec(m.Dialect.Upsert(m.Config.Table, true), m.getKey(key), raw, 0, now())
+	return err
+}
+
+// Replace sets the value for the given key, which never expires.
+func (m *SQL) Replace(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.Core.Exec(m.Dialect.Upsert(m.Config.Table, false), m.getKey(key), raw, 0)
+	return err
+}
+
+// Get returns the value for the given key.
+func (m *SQL) Get(key string, value any) error {
+	var raw []byte
+	err := m.Core.QueryRow(
+		m.query(`SELECT value FROM %s WHERE id = ? AND (expires_at = 0 OR expires_at >= ?)`),
+		m.getKey(key), now(),
+	).Scan(&raw)
+	if err == gosql.ErrNoRows {
+		return fmt.Errorf("key %s not found", key)
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, value)
+}
+
+// Delete deletes the value for the given key.
+func (m *SQL) Delete(key string) error {
+	_, err := m.Core.Exec(m.query(`DELETE FROM %s WHERE id = ?`), m.getKey(key))
+	return err
+}
+
+// Has returns true if the given key exists in the kv.
+func (m *SQL) Has(key string) bool {
+	var count int
+	err := m.Core.QueryRow(
+		m.query(`SELECT COUNT(*) FROM %s WHERE id = ? AND (expires_at = 0 OR expires_at >= ?)`),
+		m.getKey(key), now(),
+	).Scan(&count)
+	if err != nil {
+		return false
+	}
+
+	return count != 0
+}
+
+// Keys returns the keys of the kv.
+func (m *SQL) Keys() []string {
+	return m.KeysWithPrefix("")
+}
+
+// KeysWithPrefix returns the keys starting with the given prefix.
+func (m *SQL) KeysWithPrefix(prefix string) []string {
+	cond, args := m.prefixCondition(prefix)
+	rows, err := m.Core.Query(
+		m.query(`SELECT id FROM %s WHERE `+cond+` AND (expires_at = 0 OR expires_at >= ?)`),
+		append(args, now())...,
+	)
+	if err != nil {
+		return []string{}
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return []string{}
+		}
+
+		keys = append(keys, key[len(m.Config.Prefix):])
+	}
+
+	return keys
+}
+
+// KeysMatching returns the keys matching the glob pattern,
+// of the keys with its literal prefix.
+func (m *SQL) KeysMatching(pattern string) []string {
+	keys := []string{}
+	for _, key := range m.KeysWithPrefix(glob.Prefix(pattern)) {
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// Size returns the number of elements in the kv.
+func (m *SQL) Size() int {
+	cond, args := m.prefixCondition("")
+	var count int
+	err := m.Core.QueryRow(
+		m.query(`SELECT COUNT(*) FROM %s WHERE `+cond+` AND (expires_at = 0 OR expires_at >= ?)`),
+		append(args, now())...,
+	).Scan(&count)
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
+
+// DeletePrefix deletes the keys starting with the given prefix.
+func (m *SQL) DeletePrefix(prefix string) error {
+	cond, args := m.prefixCondition(prefix)
+	_, err := m.Core.Exec(m.query(`DELETE FROM %s WHERE `+cond), args...)
+	return err
+}
+
+// Clear removes all elements from the kv.
+func (m *SQL) Clear() error {
+	return m.DeletePrefix("")
+}
+
+// ForEach calls the given function for each key-value pair in the kv.
+func (m *SQL) ForEach(f func(string, interface{})) {
+	for _, key := range m.Keys() {
+		var value any
+		if err := m.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
+
+// Purge deletes the expired entries.
+func (m *SQL) Purge() error {
+	_, err := m.Core.Exec(m.query(`DELETE FROM %s WHERE expires_at > 0 AND expires_at < ?`), now())
+	return err
+}
+
+// Close stops the periodic purge, and closes the database if it was opened by New.
+func (m *SQL) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	m.wg.Wait()
+
+	if m.Config.DB != nil {
+		return nil
+	}
+
+	return m.Core.Close()
+}
+
+func (m *SQL) runPurge() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.Config.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.Purge()
+		}
+	}
+}
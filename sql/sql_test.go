@@ -0,0 +1,116 @@
+package sql
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/test"
+	_ "modernc.org/sqlite"
+)
+
+func createClient(t *testing.T, prefix string) *SQL {
+	client, err := New(&Config{
+		Driver: "sqlite",
+		DSN:    filepath.Join(t.TempDir(), "test.db"),
+		Prefix: prefix,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestKV(t *testing.T) {
+	client := createClient(t, "go-zoox-test:")
+	defer client.Close()
+
+	test.RunTestCases(t, client)
+}
+
+func TestPrefixCase(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	lower, err := New(&Config{Driver: "sqlite", DSN: dsn, Prefix: "app:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lower.Close()
+	upper, err := New(&Config{Driver: "sqlite", DSN: dsn, Prefix: "APP:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upper.Close()
+
+	lower.Set("key", "lower")
+	upper.Set("key", "upper")
+
+	if lower.Size() != 1 || len(upper.Keys()) != 1 {
+		t.Fatalf("Expected the prefixes to be case sensitive, got %d and %d", lower.Size(), len(upper.Keys()))
+	}
+
+	if err := lower.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if !upper.Has("key") {
+		t.Error("Expected key of the upper case prefix to be kept")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	client := createClient(t, "")
+	defer client.Close()
+
+	client.Set("key1", "value1", time.Millisecond)
+	client.Set("key2", "value2")
+	time.Sleep(10 * time.Millisecond)
+
+	if err := client.Purge(); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := client.Core.QueryRow(`SELECT COUNT(*) FROM kv`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row after purge, got %d", count)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	client := createClient(t, "")
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryPlaceholder(t *testing.T) {
+	m := &SQL{Config: &Config{Table: "kv"}, Dialect: Postgres}
+
+	q := m.query(`SELECT value FROM %s WHERE id = ? AND expires_at >= ?`)
+	if !strings.Contains(q, "id = $1 AND expires_at >= $2") {
+		t.Errorf("Expected postgres placeholders, got %s", q)
+	}
+}
+
+func TestGetDialect(t *testing.T) {
+	for name, expected := range map[string]string{"pgx": "postgres", "mysql": "mysql", "sqlite3": "sqlite"} {
+		d, err := GetDialect(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Name() != expected {
+			t.Errorf("Expected dialect %s for %s, got %s", expected, name, d.Name())
+		}
+	}
+
+	if _, err := GetDialect("oracle"); err == nil {
+		t.Error("Expected error for unsupported dialect")
+	}
+}
@@ -0,0 +1,324 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/glob"
+
+	// register the pure go sqlite driver
+	_ "modernc.org/sqlite"
+)
+
+// SQLite is a Key-Value Store in SQLite.
+type SQLite struct {
+	Core   *sql.DB
+	Config *SQLiteConfig
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// SQLiteConfig is the configuration for SQLite.
+type SQLiteConfig struct {
+	// Path is the path of the database file.
+	Path string
+
+	// Prefix is the prefix to use for all keys.
+	Prefix string
+
+	// Table is the table to store the entries, default is kv.
+	Table string
+
+	// CleanupInterval is the interval to delete expired entries, default is 1 minute.
+	// A negative value disables the periodic cleanup.
+	CleanupInterval time.Duration
+}
+
+// New returns a new SQLite.
+func New(cfg *SQLiteConfig) (*SQLite, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("sqlite path is required")
+	}
+	if cfg.Table == "" {
+		cfg.Table = "kv"
+	}
+	if cfg.CleanupInterval == 0 {
+		cfg.CleanupInterval = time.Minute
+	}
+
+	if dir := filepath.Dir(cfg.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	core, err := sql.Open("sqlite", cfg.Path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	m := &SQLite{
+		Core:   core,
+		Config: cfg,
+		done:   make(chan struct{}),
+	}
+
+	if err := m.migrate(); err != nil {
+		core.Close()
+		return nil, err
+	}
+
+	if cfg.CleanupInterval > 0 {
+		m.wg.Add(1)
+		go m.runCleanup()
+	}
+
+	return m, nil
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+func (m *SQLite) migrate() error {
+	table := m.Config.Table
+	_, err := m.Core.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (
+			key TEXT PRIMARY KEY,
+			value BLOB NOT NULL,
+			expires_at INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE INDEX IF NOT EXISTS %[1]s_expires_at ON %[1]s (expires_at) WHERE expires_at > 0;
+	`, table))
+	return err
+}
+
+func (m *SQLite) getKey(key string) string {
+	return m.Config.Prefix + key
+}
+
+// prefixRange returns the condition on key, and its arguments, matching all keys
+// with the configured prefix and the given one.
+// It is a byte-wise range, which uses the primary key index, while LIKE would ignore the ASCII case.
+func (m *SQLite) prefixRange(prefix string) (string, []any) {
+	from := m.getKey(prefix)
+	if to, ok := upperBound(from); ok {
+		return "key >= ? AND key < ?", []any{from, to}
+	}
+
+	return "key >= ?", []any{from}
+}
+
+// upperBound returns the least string greater than all strings with the prefix,
+// or false if there is none, when the prefix is empty or all 0xff.
+func upperBound(prefix string) (string, bool) {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1]), true
+		}
+	}
+
+	return "", false
+}
+
+// Set sets the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge miliseconds.
+func (m *SQLite) Set(key string, value any, maxAge ...time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	nowX := now()
+	if len(maxAge) > 0 {
+		expiresAt := nowX + int64(maxAge[0]/time.Millisecond)
+		_, err = m.Core.Exec(fmt.Sprintf(`
+			INSERT INTO %[1]s (key, value, expires_at) VALUES (?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at
+		`, m.Config.Table), m.getKey(key), raw, expiresAt)
+		return err
+	}
+
+	// keep the origin expiresAt, unless it has already expired
+	_, err = m.Core.Exec(fmt.Sprintf(`
+		INSERT INTO %[1]s (key, value, expires_at) VALUES (?, ?, 0)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value,
+			expires_at = CASE WHEN %[1]s.expires_at >= ? THEN %[1]s.expires_at ELSE 0 END
+	`, m.Config.Table), m.getKey(key), raw, nowX)
+	return err
+}
+
+// Replace sets the value for the given key, which never expires.
+func (m *SQLite) Replace(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.Core.Exec(fmt.Sprintf(`
+		INSERT INTO %[1]s (key, value, expires_at) VALUES (?, ?, 0)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = 0
+	`, m.Config.Table), m.getKey(key), raw)
+	return err
+}
+
+// Get returns the value for the given key.
+func (m *SQLite) Get(key string, value any) error {
+	var raw []byte
+	err := m.Core.QueryRow(fmt.Sprintf(
+		`SELECT value FROM %s WHERE key = ? AND (expires_at = 0 OR expires_at >= ?)`,
+		m.Config.Table,
+	), m.getKey(key), now()).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("key %s not found", key)
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, value)
+}
+
+// Delete deletes the value for the given key.
+func (m *SQLite) Delete(key string) error {
+	_, err := m.Core.Exec(fmt.Sprintf(`DELETE FROM %s WHERE key = ?`, m.Config.Table), m.getKey(key))
+	return err
+}
+
+// Has returns true if the given key exists in the kv.
+func (m *SQLite) Has(key string) bool {
+	var count int
+	err := m.Core.QueryRow(fmt.Sprintf(
+		`SELECT COUNT(*) FROM %s WHERE key = ? AND (expires_at = 0 OR expires_at >= ?)`,
+		m.Config.Table,
+	), m.getKey(key), now()).Scan(&count)
+	if err != nil {
+		return false
+	}
+
+	return count != 0
+}
+
+// Keys returns the keys of the kv.
+func (m *SQLite) Keys() []string {
+	return m.KeysWithPrefix("")
+}
+
+// KeysWithPrefix returns the keys starting with the given prefix.
+func (m *SQLite) KeysWithPrefix(prefix string) []string {
+	cond, args := m.prefixRange(prefix)
+	rows, err := m.Core.Query(fmt.Sprintf(
+		`SELECT key FROM %s WHERE %s AND (expires_at = 0 OR expires_at >= ?)`,
+		m.Config.Table, cond,
+	), append(args, now())...)
+	if err != nil {
+		return []string{}
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return []string{}
+		}
+
+		keys = append(keys, key[len(m.Config.Prefix):])
+	}
+
+	return keys
+}
+
+// KeysMatching returns the keys matching the glob pattern,
+// of the keys with its literal prefix.
+func (m *SQLite) KeysMatching(pattern string) []string {
+	keys := []string{}
+	for _, key := range m.KeysWithPrefix(glob.Prefix(pattern)) {
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// Size returns the number of elements in the kv.
+func (m *SQLite) Size() int {
+	cond, args := m.prefixRange("")
+	var count int
+	err := m.Core.QueryRow(fmt.Sprintf(
+		`SELECT COUNT(*) FROM %s WHERE %s AND (expires_at = 0 OR expires_at >= ?)`,
+		m.Config.Table, cond,
+	), append(args, now())...).Scan(&count)
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
+
+// DeletePrefix deletes the keys starting with the given prefix.
+func (m *SQLite) DeletePrefix(prefix string) error {
+	cond, args := m.prefixRange(prefix)
+	_, err := m.Core.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s`, m.Config.Table, cond), args...)
+	return err
+}
+
+// Clear removes all elements from the kv.
+func (m *SQLite) Clear() error {
+	return m.DeletePrefix("")
+}
+
+// ForEach calls the given function for each key-value pair in the kv.
+func (m *SQLite) ForEach(f func(string, interface{})) {
+	for _, key := range m.Keys() {
+		var value any
+		if err := m.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
+
+// Cleanup deletes the expired entries.
+func (m *SQLite) Cleanup() error {
+	_, err := m.Core.Exec(fmt.Sprintf(
+		`DELETE FROM %s WHERE expires_at > 0 AND expires_at < ?`,
+		m.Config.Table,
+	), now())
+	return err
+}
+
+// Close stops the periodic cleanup and closes the database.
+func (m *SQLite) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	m.wg.Wait()
+
+	return m.Core.Close()
+}
+
+func (m *SQLite) runCleanup() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.Config.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.Cleanup()
+		}
+	}
+}
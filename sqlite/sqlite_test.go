@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/test"
+)
+
+func createClient(t *testing.T, prefix string) *SQLite {
+	client, err := New(&SQLiteConfig{
+		Path:   filepath.Join(t.TempDir(), "test.db"),
+		Prefix: prefix,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestKV(t *testing.T) {
+	client := createClient(t, "go-zoox-test:")
+	defer client.Close()
+
+	test.RunTestCases(t, client)
+}
+
+func TestPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	a, err := New(&SQLiteConfig{Path: path, Prefix: "a_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := New(&SQLiteConfig{Path: path, Prefix: "ab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	a.Set("key", "a")
+	b.Set("key", "b")
+
+	// "_" must not match as a LIKE wildcard
+	if a.Size() != 1 || b.Size() != 1 {
+		t.Fatalf("Expected size 1 for each prefix, got %d and %d", a.Size(), b.Size())
+	}
+
+	if err := a.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if !b.Has("key") {
+		t.Error("Expected key in another prefix to be kept")
+	}
+}
+
+func TestPrefixCase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	lower, err := New(&SQLiteConfig{Path: path, Prefix: "app:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lower.Close()
+	upper, err := New(&SQLiteConfig{Path: path, Prefix: "APP:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upper.Close()
+
+	lower.Set("key", "lower")
+	upper.Set("key", "upper")
+
+	if lower.Size() != 1 || len(upper.Keys()) != 1 {
+		t.Fatalf("Expected the prefixes to be case sensitive, got %d and %d", lower.Size(), len(upper.Keys()))
+	}
+
+	if err := lower.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if !upper.Has("key") {
+		t.Error("Expected key of the upper case prefix to be kept")
+	}
+}
+
+func TestUpperBound(t *testing.T) {
+	for prefix, want := range map[string]string{"": "", "ab": "ac", "a\xff": "b", "\xff\xff": ""} {
+		if got, _ := upperBound(prefix); got != want {
+			t.Errorf("Expected upperBound(%q) to be %q, got %q", prefix, want, got)
+		}
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	client := createClient(t, "")
+	defer client.Close()
+
+	client.Set("key1", "value1", time.Millisecond)
+	client.Set("key2", "value2")
+	time.Sleep(10 * time.Millisecond)
+
+	if err := client.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := client.Core.QueryRow(`SELECT COUNT(*) FROM kv`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row after cleanup, got %d", count)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	client := createClient(t, "")
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,252 @@
+package swr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/typing"
+)
+
+// SWR is a stale-while-revalidate cache on a KV.
+//
+// Each value has a soft expiry besides the hard expiry of maxAge.
+// After the soft expiry, the stale value is still returned,
+// while the loader registered for the key refreshes it in the background.
+type SWR struct {
+	KV     typing.KV
+	Config *Config
+
+	mu         sync.RWMutex
+	loaders    map[string]Loader
+	refreshing map[string]bool
+	wg         sync.WaitGroup
+}
+
+// Loader loads the fresh value of the key.
+type Loader func(key string) (any, error)
+
+// Config is the configuration for SWR.
+type Config struct {
+	// SoftTTL is the time after which a value is stale, default is 1 minute.
+	SoftTTL time.Duration
+
+	// MaxAge is the hard expiry of the refreshed values, 0 for never.
+	// The values set with a maxAge keep it on refresh.
+	MaxAge time.Duration
+
+	// OnError is called with the errors of the background refreshes, optional.
+	OnError func(key string, err error)
+}
+
+// entry is the value stored in the KV.
+type entry struct {
+	Value json.RawMessage
+	// SoftExpiresAt is the unix milliseconds after which the value is stale.
+	SoftExpiresAt int64
+	// MaxAge is the hard expiry of the value in milliseconds, 0 for never, reused on refresh.
+	MaxAge int64
+}
+
+// New returns a new SWR on kv.
+func New(kv typing.KV, cfg ...*Config) *SWR {
+	cfgX := &Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		cfgX = cfg[0]
+	}
+
+	if cfgX.SoftTTL <= 0 {
+		cfgX.SoftTTL = time.Minute
+	}
+
+	return &SWR{
+		KV:         kv,
+		Config:     cfgX,
+		loaders:    make(map[string]Loader),
+		refreshing: make(map[string]bool),
+	}
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+// Register registers the loader of the keys with the prefix,
+// the loader of the longest matching prefix refreshes a key.
+func (s *SWR) Register(prefix string, loader Loader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loaders[prefix] = loader
+}
+
+func (s *SWR) loader(key string) Loader {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var loader Loader
+	longest := -1
+	for prefix, l := range s.loaders {
+		if strings.HasPrefix(key, prefix) && len(prefix) > longest {
+			loader = l
+			longest = len(prefix)
+		}
+	}
+
+	return loader
+}
+
+// Wait waits for the background refreshes in flight.
+func (s *SWR) Wait() {
+	s.wg.Wait()
+}
+
+// Set sets the value for the given key, which is stale after SoftTTL.
+// If maxAge is greater than 0, then the value will be expired after maxAge,
+// else the origin expiry of the key is kept.
+func (s *SWR) Set(key string, value any, maxAge ...time.Duration) error {
+	var maxAgeX time.Duration
+	if len(maxAge) > 0 {
+		maxAgeX = maxAge[0]
+	}
+
+	return s.SetWithSoftTTL(key, value, s.Config.SoftTTL, maxAgeX)
+}
+
+// SetWithSoftTTL sets the value for the given key, which is stale after softTTL,
+// and expired after maxAge if greater than 0, else the origin expiry of the key is kept.
+func (s *SWR) SetWithSoftTTL(key string, value any, softTTL, maxAge time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	e := &entry{
+		Value:         raw,
+		SoftExpiresAt: now() + softTTL.Milliseconds(),
+		MaxAge:        maxAge.Milliseconds(),
+	}
+
+	if maxAge > 0 {
+		return s.KV.Set(key, e, maxAge)
+	}
+
+	return s.KV.Set(key, e)
+}
+
+// Get returns the value for the given key, even if stale.
+func (s *SWR) Get(key string, value any) error {
+	_, err := s.GetWithStale(key, value)
+	return err
+}
+
+// GetWithStale returns the value for the given key, and whether it is stale.
+// A stale value triggers a background refresh, if a loader is registered for the key.
+func (s *SWR) GetWithStale(key string, value any) (stale bool, err error) {
+	e, err := s.get(key, value)
+	if err != nil {
+		return false, err
+	}
+
+	stale = now() >= e.SoftExpiresAt
+	if stale {
+		s.refresh(key, time.Duration(e.MaxAge)*time.Millisecond)
+	}
+
+	return stale, nil
+}
+
+// get decodes the value of the key, and returns its entry.
+func (s *SWR) get(key string, value any) (*entry, error) {
+	if !s.KV.Has(key) {
+		return nil, fmt.Errorf("key %s not found", key)
+	}
+
+	var e entry
+	if err := s.KV.Get(key, &e); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(e.Value, value); err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// refresh loads the key in the background, once at a time per key.
+func (s *SWR) refresh(key string, maxAge time.Duration) {
+	loader := s.loader(key)
+	if loader == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.refreshing[key] {
+		s.mu.Unlock()
+		return
+	}
+	s.refreshing[key] = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.refreshing, key)
+			s.mu.Unlock()
+			s.wg.Done()
+		}()
+
+		if maxAge <= 0 {
+			maxAge = s.Config.MaxAge
+		}
+
+		value, err := loader(key)
+		if err == nil {
+			err = s.SetWithSoftTTL(key, value, s.Config.SoftTTL, maxAge)
+		}
+		if err != nil && s.Config.OnError != nil {
+			s.Config.OnError(key, err)
+		}
+	}()
+}
+
+// Delete deletes the value for the given key.
+func (s *SWR) Delete(key string) error {
+	return s.KV.Delete(key)
+}
+
+// Has returns true if the given key exists in the kv, even if stale.
+func (s *SWR) Has(key string) bool {
+	return s.KV.Has(key)
+}
+
+// Keys returns the keys of the kv.
+func (s *SWR) Keys() []string {
+	return s.KV.Keys()
+}
+
+// Size returns the number of elements in the kv.
+func (s *SWR) Size() int {
+	return s.KV.Size()
+}
+
+// Clear removes all elements from the kv.
+func (s *SWR) Clear() error {
+	return s.KV.Clear()
+}
+
+// ForEach calls the given function for each key-value pair in the kv, without refreshing.
+func (s *SWR) ForEach(f func(string, interface{})) {
+	for _, key := range s.Keys() {
+		var value any
+		if _, err := s.get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
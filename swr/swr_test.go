@@ -0,0 +1,109 @@
+package swr
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/test"
+)
+
+func TestKV(t *testing.T) {
+	test.RunTestCases(t, New(memory.New()))
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	cache := New(memory.New(), &Config{SoftTTL: 50 * time.Millisecond})
+
+	var loads int32
+	cache.Register("price:", func(key string) (any, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "fresh", nil
+	})
+
+	cache.Set("price:1", "initial", time.Minute)
+
+	var value string
+	if stale, err := cache.GetWithStale("price:1", &value); err != nil || stale || value != "initial" {
+		t.Fatalf("Expected fresh initial, got %q, stale %v, %v", value, stale, err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if stale, err := cache.GetWithStale("price:1", &value); err != nil || !stale || value != "initial" {
+			t.Fatalf("Expected stale initial, got %q, stale %v, %v", value, stale, err)
+		}
+	}
+
+	cache.Wait()
+	if loads != 1 {
+		t.Errorf("Expected 1 refresh, got %d", loads)
+	}
+	if stale, err := cache.GetWithStale("price:1", &value); err != nil || stale || value != "fresh" {
+		t.Errorf("Expected refreshed value, got %q, stale %v, %v", value, stale, err)
+	}
+}
+
+func TestSetKeepsTTL(t *testing.T) {
+	kv := memory.New()
+	cache := New(kv, &Config{SoftTTL: time.Minute})
+
+	cache.Set("key", "value", time.Minute)
+	cache.Set("key", "other")
+
+	if ttl, err := kv.TTL("key"); err != nil || ttl <= 0 {
+		t.Errorf("Expected the hard expiry kept, got %s, %v", ttl, err)
+	}
+
+	var value string
+	if err := cache.Get("key", &value); err != nil || value != "other" {
+		t.Errorf("Expected other, got %q, %v", value, err)
+	}
+}
+
+func TestRefreshError(t *testing.T) {
+	var failed string
+	cache := New(memory.New(), &Config{
+		SoftTTL: time.Millisecond,
+		OnError: func(key string, err error) { failed = key },
+	})
+	cache.Register("", func(key string) (any, error) {
+		return nil, errors.New("upstream down")
+	})
+
+	cache.Set("key", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	var value int
+	if stale, err := cache.GetWithStale("key", &value); err != nil || !stale || value != 1 {
+		t.Errorf("Expected stale value, got %d, stale %v, %v", value, stale, err)
+	}
+
+	cache.Wait()
+	if failed != "key" {
+		t.Errorf("Expected OnError for key, got %q", failed)
+	}
+	if stale, _ := cache.GetWithStale("key", &value); !stale || value != 1 {
+		t.Error("Expected the stale value to be kept on refresh error")
+	}
+	cache.Wait()
+}
+
+func TestLongestPrefix(t *testing.T) {
+	cache := New(memory.New())
+	cache.Register("user:", func(key string) (any, error) { return "user", nil })
+	cache.Register("user:admin:", func(key string) (any, error) { return "admin", nil })
+
+	if v, _ := cache.loader("user:admin:1")(""); v != "admin" {
+		t.Errorf("Expected admin loader, got %v", v)
+	}
+	if v, _ := cache.loader("user:1")(""); v != "user" {
+		t.Errorf("Expected user loader, got %v", v)
+	}
+	if cache.loader("order:1") != nil {
+		t.Error("Expected no loader")
+	}
+}
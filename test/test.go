@@ -19,6 +19,8 @@ func RunTestCases(t *testing.T, client typing.KV, casesDisabled ...[]string) {
 		"keys":    true,
 		"forEach": true,
 		"maxAge":  true,
+		"prefix":  true,
+		"replace": true,
 	}
 	if len(casesDisabled) > 0 {
 		for _, c := range casesDisabled[0] {
@@ -41,6 +43,14 @@ func RunTestCases(t *testing.T, client typing.KV, casesDisabled ...[]string) {
 	if casesDisabledX["maxAge"] {
 		RunMaxAgeTestCase(t, client)
 	}
+
+	if casesDisabledX["prefix"] {
+		RunPrefixTestCase(t, client)
+	}
+
+	if casesDisabledX["replace"] {
+		RunReplaceTestCase(t, client)
+	}
 }
 
 // RunMainTestCase tests the main functionality.
@@ -157,6 +167,97 @@ func RunKeysTestCase(t *testing.T, client typing.KV) {
 	}
 }
 
+// RunPrefixTestCase tests KeysWithPrefix, KeysMatching and DeletePrefix,
+// if the client implements typing.Prefix and typing.Glob.
+func RunPrefixTestCase(t *testing.T, client typing.KV) {
+	t.Log("Testing prefix test case")
+
+	prefix, ok := client.(typing.Prefix)
+	if !ok {
+		return
+	}
+	matcher, ok := client.(typing.Glob)
+	if !ok {
+		return
+	}
+
+	if err := client.Clear(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"user:1", "user:2", "user:10", "users", "session:1", "a*b"} {
+		value := key
+		if err := client.Set(key, &value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sorted := func(keys []string) string {
+		sort.Strings(keys)
+		return strings.Join(keys, ",")
+	}
+
+	if keys := sorted(prefix.KeysWithPrefix("user:")); keys != "user:1,user:10,user:2" {
+		t.Errorf("Expected keys with prefix user: to be user:1,user:10,user:2, got %s", keys)
+	}
+	if keys := sorted(prefix.KeysWithPrefix("a*")); keys != "a*b" {
+		t.Errorf("Expected keys with prefix a* to be a*b, got %s", keys)
+	}
+	if keys := sorted(matcher.KeysMatching("user:?")); keys != "user:1,user:2" {
+		t.Errorf("Expected keys matching user:? to be user:1,user:2, got %s", keys)
+	}
+	if keys := sorted(matcher.KeysMatching("s*:1")); keys != "session:1" {
+		t.Errorf("Expected keys matching s*:1 to be session:1, got %s", keys)
+	}
+
+	if err := prefix.DeletePrefix("user"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := sorted(client.Keys()); keys != "a*b,session:1" {
+		t.Errorf("Expected keys after DeletePrefix to be a*b,session:1, got %s", keys)
+	}
+}
+
+// RunReplaceTestCase tests Replace, if the client implements typing.Replacer.
+func RunReplaceTestCase(t *testing.T, client typing.KV) {
+	t.Log("Testing replace test case")
+
+	replacer, ok := client.(typing.Replacer)
+	if !ok {
+		return
+	}
+
+	if err := client.Clear(); err != nil {
+		t.Fatal(err)
+	}
+
+	value1 := "value1"
+	if err := client.Set("key1", &value1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	value2 := "value2"
+	if err := replacer.Replace("key1", &value2); err != nil {
+		t.Fatal(err)
+	}
+	if err := replacer.Replace("key2", &value2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"key1", "key2"} {
+		var value string
+		if err := client.Get(key, &value); err != nil || value != value2 {
+			t.Errorf("Expected value of %s to be 'value2', got %s", key, value)
+		}
+
+		if ttl, ok := client.(typing.TTL); ok {
+			if d, err := ttl.TTL(key); err != nil || d >= 0 {
+				t.Errorf("Expected %s to never expire after Replace, got %s, %v", key, d, err)
+			}
+		}
+	}
+}
+
 // RunForEachTestCase tests the ForEach functionality.
 func RunForEachTestCase(t *testing.T, client typing.KV) {
 	t.Log("Testing forEach test case")
@@ -197,7 +298,7 @@ func RunMaxAgeTestCase(t *testing.T, client typing.KV) {
 
 	key1 := "key1"
 	value1 := "value1"
-	if err := client.Set(key1, &value1, 500); err != nil {
+	if err := client.Set(key1, &value1, 500*time.Millisecond); err != nil {
 		t.Fatal(err)
 	}
 
@@ -0,0 +1,60 @@
+package tiered
+
+import (
+	"context"
+	"io"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// PubSub broadcasts the invalidation messages between the instances.
+type PubSub interface {
+	// Publish sends the message to all subscribers, including the publisher.
+	Publish(message []byte) error
+	// Subscribe calls handler for each message, until the returned Closer is closed.
+	Subscribe(handler func(message []byte)) (io.Closer, error)
+}
+
+// RedisPubSub is a PubSub on a Redis channel.
+type RedisPubSub struct {
+	Core    *goredis.Client
+	Channel string
+}
+
+// NewRedisPubSub returns a new RedisPubSub, default channel is go-zoox:kv:tiered.
+func NewRedisPubSub(core *goredis.Client, channel ...string) *RedisPubSub {
+	channelX := "go-zoox:kv:tiered"
+	if len(channel) > 0 && channel[0] != "" {
+		channelX = channel[0]
+	}
+
+	return &RedisPubSub{
+		Core:    core,
+		Channel: channelX,
+	}
+}
+
+// Publish sends the message to the channel.
+func (p *RedisPubSub) Publish(message []byte) error {
+	return p.Core.Publish(context.Background(), p.Channel, message).Err()
+}
+
+// Subscribe subscribes the channel, and calls handler for each message in a goroutine.
+func (p *RedisPubSub) Subscribe(handler func(message []byte)) (io.Closer, error) {
+	ctx := context.Background()
+	sub := p.Core.Subscribe(ctx, p.Channel)
+
+	// wait for the confirmation, so that no message is missed after Subscribe returns
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	go func() {
+		for msg := range sub.Channel() {
+			handler([]byte(msg.Payload))
+		}
+	}()
+
+	return sub, nil
+}
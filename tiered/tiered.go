@@ -0,0 +1,256 @@
+package tiered
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/typing"
+)
+
+// Tiered is a two-level Key-Value Store, with a bounded memory L1 in front of any L2.
+//
+// Reads are served by L1, and populated from L2 on miss.
+// Writes go through to both levels, and invalidate the L1 of the other instances if PubSub is set.
+// L1 values may be served up to L1TTL after they are changed in L2 by other writers.
+type Tiered struct {
+	L1     *memory.Memory
+	L2     typing.KV
+	Config *Config
+
+	// id identifies the instance in the invalidations, to skip its own.
+	id string
+	// writes is bumped before and after every write to L2, so that a read concurrent with the write
+	// does not populate L1 with an old value.
+	writes uint64
+
+	subscription io.Closer
+}
+
+// Config is the configuration for Tiered.
+type Config struct {
+	// L1MaxSize is the max number of entries in L1, default is 10000.
+	L1MaxSize int
+
+	// L1TTL is the time to live of the entries in L1, default is 1 minute.
+	// It is capped by the maxAge of Set.
+	L1TTL time.Duration
+
+	// PubSub broadcasts the invalidations to the other instances, optional.
+	PubSub PubSub
+}
+
+// invalidation is the message of PubSub.
+type invalidation struct {
+	ID string `json:"id"`
+	// Key is the key to invalidate, or empty for all keys.
+	Key string `json:"key,omitempty"`
+}
+
+// New returns a new Tiered in front of l2.
+func New(l2 typing.KV, cfg ...*Config) (*Tiered, error) {
+	cfgX := &Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		cfgX = cfg[0]
+	}
+
+	if cfgX.L1MaxSize <= 0 {
+		cfgX.L1MaxSize = 10000
+	}
+	if cfgX.L1TTL <= 0 {
+		cfgX.L1TTL = time.Minute
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	t := &Tiered{
+		L1:     memory.New(&memory.Config{MaxSize: cfgX.L1MaxSize}),
+		L2:     l2,
+		Config: cfgX,
+		id:     hex.EncodeToString(id),
+	}
+
+	if cfgX.PubSub != nil {
+		subscription, err := cfgX.PubSub.Subscribe(t.onInvalidation)
+		if err != nil {
+			return nil, err
+		}
+		t.subscription = subscription
+	}
+
+	return t, nil
+}
+
+// Close stops receiving the invalidations of the other instances.
+func (t *Tiered) Close() error {
+	if t.subscription == nil {
+		return nil
+	}
+
+	return t.subscription.Close()
+}
+
+func (t *Tiered) onInvalidation(message []byte) {
+	var inv invalidation
+	if err := json.Unmarshal(message, &inv); err != nil || inv.ID == t.id {
+		return
+	}
+
+	atomic.AddUint64(&t.writes, 1)
+	if inv.Key == "" {
+		t.L1.Clear()
+	} else {
+		t.L1.Delete(inv.Key)
+	}
+}
+
+func (t *Tiered) publish(key string) error {
+	if t.Config.PubSub == nil {
+		return nil
+	}
+
+	message, err := json.Marshal(&invalidation{ID: t.id, Key: key})
+	if err != nil {
+		return err
+	}
+
+	return t.Config.PubSub.Publish(message)
+}
+
+// setL1 caches the JSON of the value in L1, so that the callers never share a value.
+func (t *Tiered) setL1(key string, value any, maxAge time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	ttl := t.Config.L1TTL
+	if maxAge > 0 && maxAge < ttl {
+		ttl = maxAge
+	}
+
+	message := json.RawMessage(raw)
+	return t.L1.Set(key, &message, ttl)
+}
+
+// fillL1 caches the value in L1, no longer than the remaining TTL in L2 if L2 implements typing.TTL,
+// or drops it from L1 if it is gone from L2.
+func (t *Tiered) fillL1(key string, value any) error {
+	var maxAge time.Duration
+	if ttl, ok := t.L2.(typing.TTL); ok {
+		d, err := ttl.TTL(key)
+		if err != nil || d == 0 {
+			// expired since written or read
+			t.L1.Delete(key)
+			return nil
+		}
+		if d > 0 {
+			maxAge = d
+		}
+	}
+
+	return t.setL1(key, value, maxAge)
+}
+
+// Set sets the value for the given key, in L2 and then in L1.
+// If maxAge is greater than 0, then the value will be expired after maxAge.
+// Otherwise the expiry in L2 is kept, which caps the one in L1 like on read.
+func (t *Tiered) Set(key string, value any, maxAge ...time.Duration) error {
+	atomic.AddUint64(&t.writes, 1)
+
+	err := t.L2.Set(key, value, maxAge...)
+	atomic.AddUint64(&t.writes, 1)
+	if err != nil {
+		t.L1.Delete(key)
+		return err
+	}
+
+	if len(maxAge) > 0 {
+		err = t.setL1(key, value, maxAge[0])
+	} else {
+		err = t.fillL1(key, value)
+	}
+	if err != nil {
+		t.L1.Delete(key)
+	}
+
+	return t.publish(key)
+}
+
+// Get returns the value for the given key, from L1, or from L2 which populates L1.
+func (t *Tiered) Get(key string, value any) error {
+	var raw json.RawMessage
+	if t.L1.Has(key) && t.L1.Get(key, &raw) == nil {
+		return json.Unmarshal(raw, value)
+	}
+
+	writes := atomic.LoadUint64(&t.writes)
+	if !t.L2.Has(key) {
+		return fmt.Errorf("key %s not found", key)
+	}
+	if err := t.L2.Get(key, value); err != nil {
+		return err
+	}
+
+	if atomic.LoadUint64(&t.writes) == writes {
+		t.fillL1(key, value)
+	}
+
+	return nil
+}
+
+// Delete deletes the value for the given key, in both levels.
+func (t *Tiered) Delete(key string) error {
+	atomic.AddUint64(&t.writes, 1)
+	t.L1.Delete(key)
+
+	err := t.L2.Delete(key)
+	atomic.AddUint64(&t.writes, 1)
+	if err != nil {
+		return err
+	}
+
+	return t.publish(key)
+}
+
+// Has returns true if the given key exists in the kv.
+func (t *Tiered) Has(key string) bool {
+	return t.L1.Has(key) || t.L2.Has(key)
+}
+
+// Keys returns the keys of L2.
+func (t *Tiered) Keys() []string {
+	return t.L2.Keys()
+}
+
+// Size returns the number of elements in L2.
+func (t *Tiered) Size() int {
+	return t.L2.Size()
+}
+
+// Clear removes all elements from both levels.
+func (t *Tiered) Clear() error {
+	atomic.AddUint64(&t.writes, 1)
+	t.L1.Clear()
+
+	err := t.L2.Clear()
+	atomic.AddUint64(&t.writes, 1)
+	if err != nil {
+		return err
+	}
+
+	return t.publish("")
+}
+
+// ForEach calls the given function for each key-value pair in L2.
+func (t *Tiered) ForEach(f func(string, interface{})) {
+	t.L2.ForEach(f)
+}
@@ -0,0 +1,214 @@
+package tiered
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/go-zoox/dotenv"
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/test"
+	"github.com/go-zoox/kv/typing"
+)
+
+// countingKV counts the reads of a KV.
+type countingKV struct {
+	typing.KV
+	sync.Mutex
+	gets int
+}
+
+func (c *countingKV) Get(key string, value any) error {
+	c.Lock()
+	c.gets++
+	c.Unlock()
+
+	return c.KV.Get(key, value)
+}
+
+// fakePubSub delivers the messages synchronously to the subscribers.
+type fakePubSub struct {
+	sync.Mutex
+	handlers []func(message []byte)
+}
+
+func (p *fakePubSub) Publish(message []byte) error {
+	p.Lock()
+	handlers := append([]func(message []byte){}, p.handlers...)
+	p.Unlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+func (p *fakePubSub) Subscribe(handler func(message []byte)) (io.Closer, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.handlers = append(p.handlers, handler)
+	return io.NopCloser(&bytes.Buffer{}), nil
+}
+
+func TestKV(t *testing.T) {
+	client, err := New(memory.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.RunTestCases(t, client)
+}
+
+func TestReadThrough(t *testing.T) {
+	l2 := &countingKV{KV: memory.New()}
+	client, _ := New(l2, &Config{L1TTL: 50 * time.Millisecond})
+
+	value := "value"
+	l2.Set("key", &value)
+
+	for i := 0; i < 3; i++ {
+		var got string
+		if err := client.Get("key", &got); err != nil || got != "value" {
+			t.Fatalf("Expected value, got %q, %v", got, err)
+		}
+	}
+	if l2.gets != 1 {
+		t.Errorf("Expected 1 read of L2, got %d", l2.gets)
+	}
+
+	// L1 expires after L1TTL
+	time.Sleep(100 * time.Millisecond)
+	var got string
+	client.Get("key", &got)
+	if l2.gets != 2 {
+		t.Errorf("Expected L1 to expire, got %d reads of L2", l2.gets)
+	}
+
+	if err := client.Get("missing", &got); err == nil {
+		t.Error("Expected missing key to be an error")
+	}
+}
+
+func TestReadThroughTTL(t *testing.T) {
+	l2 := memory.New()
+	client, _ := New(l2)
+
+	value := "value"
+	l2.Set("key", &value, 50*time.Millisecond)
+
+	var got string
+	if err := client.Get("key", &got); err != nil || got != "value" {
+		t.Fatalf("Expected value, got %q, %v", got, err)
+	}
+
+	// L1 expires with L2, before L1TTL
+	time.Sleep(100 * time.Millisecond)
+	if err := client.Get("key", &got); err == nil {
+		t.Error("Expected key to expire in L1 with L2")
+	}
+}
+
+func TestWriteThroughTTL(t *testing.T) {
+	l2 := memory.New()
+	client, _ := New(l2)
+
+	value := "value"
+	client.Set("key", &value, 50*time.Millisecond)
+
+	// the expiry in L2 is kept, and caps the one in L1
+	value = "value2"
+	client.Set("key", &value)
+
+	time.Sleep(100 * time.Millisecond)
+	var got string
+	if err := client.Get("key", &got); err == nil {
+		t.Errorf("Expected key to expire in L1 with L2, got %q", got)
+	}
+}
+
+func TestWriteThrough(t *testing.T) {
+	l2 := &countingKV{KV: memory.New()}
+	client, _ := New(l2)
+
+	value := "value"
+	client.Set("key", &value)
+
+	var got string
+	if err := client.Get("key", &got); err != nil || got != "value" {
+		t.Fatalf("Expected value, got %q, %v", got, err)
+	}
+	if l2.gets != 0 || !l2.Has("key") {
+		t.Errorf("Expected write through to both levels, got %d reads of L2", l2.gets)
+	}
+
+	client.Delete("key")
+	if client.L1.Has("key") || l2.Has("key") {
+		t.Error("Expected delete from both levels")
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	pubsub := &fakePubSub{}
+	l2 := memory.New()
+	a, _ := New(l2, &Config{PubSub: pubsub})
+	b, _ := New(l2, &Config{PubSub: pubsub})
+
+	value := "v1"
+	a.Set("key", &value)
+
+	var got string
+	b.Get("key", &got)
+	if !b.L1.Has("key") {
+		t.Fatal("Expected b to cache the key in L1")
+	}
+
+	value2 := "v2"
+	a.Set("key", &value2)
+	if !a.L1.Has("key") {
+		t.Error("Expected a to keep its own write in L1")
+	}
+	if err := b.Get("key", &got); err != nil || got != "v2" {
+		t.Errorf("Expected b to read v2 after invalidation, got %q, %v", got, err)
+	}
+
+	a.Clear()
+	if b.L1.Size() != 0 {
+		t.Error("Expected clear to invalidate b")
+	}
+}
+
+func TestRedisPubSub(t *testing.T) {
+	uri := dotenv.Get("REDIS_URI")
+	if uri == "" {
+		t.Skip("REDIS_URI is not set")
+	}
+
+	opt, err := goredis.ParseURL(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core := goredis.NewClient(opt)
+	defer core.Close()
+
+	pubsub := NewRedisPubSub(core, "go-zoox-test:tiered")
+	received := make(chan []byte, 1)
+	sub, err := pubsub.Subscribe(func(message []byte) { received <- message })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	pubsub.Publish([]byte("hello"))
+	select {
+	case message := <-received:
+		if string(message) != "hello" {
+			t.Errorf("Expected hello, got %s", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the message to be received")
+	}
+}
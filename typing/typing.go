@@ -1,6 +1,12 @@
 package typing
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is wrapped by the errors of TTL for a missing or expired key.
+var ErrNotFound = errors.New("not found")
 
 // KV is a Key-Value Store
 type KV interface {
@@ -22,6 +28,54 @@ type KV interface {
 	ForEach(func(key string, value any))
 }
 
+// TTL is implemented by the KVs which could report the time to live of a key.
+type TTL interface {
+	// TTL returns the remaining time to live of the given key,
+	// or a negative duration if the key never expires.
+	// The error wraps ErrNotFound if the key does not exist.
+	TTL(key string) (time.Duration, error)
+}
+
+// Prefix is implemented by the KVs which could list and delete the keys of a prefix natively.
+type Prefix interface {
+	// KeysWithPrefix returns the keys starting with the given prefix.
+	KeysWithPrefix(prefix string) []string
+	// DeletePrefix deletes the keys starting with the given prefix.
+	DeletePrefix(prefix string) error
+}
+
+// Glob is implemented by the KVs which could list the keys matching a glob pattern.
+type Glob interface {
+	// KeysMatching returns the keys matching the redis glob pattern,
+	// which supports *, ?, [abc], [^abc], [a-z] and \ to escape.
+	KeysMatching(pattern string) []string
+}
+
+// Replacer is implemented by the KVs which could set a value and drop its expiry at once.
+type Replacer interface {
+	// Replace sets the value for the given key, which never expires,
+	// while Set without maxAge keeps the origin expiry.
+	Replace(key string, value any) error
+}
+
+// Replace sets the value for the given key, like Set, but drops the origin expiry if maxAge is not given.
+// It is atomic if kv implements Replacer, otherwise the key is deleted before it is set again.
+func Replace(kv KV, key string, value any, maxAge ...time.Duration) error {
+	if len(maxAge) > 0 {
+		return kv.Set(key, value, maxAge...)
+	}
+
+	if r, ok := kv.(Replacer); ok {
+		return r.Replace(key, value)
+	}
+
+	if err := kv.Delete(key); err != nil {
+		return err
+	}
+
+	return kv.Set(key, value)
+}
+
 // Config is the configuration used to create a new KV.
 type Config struct {
 	Engine string
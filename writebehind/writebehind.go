@@ -0,0 +1,343 @@
+package writebehind
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-zoox/kv/typing"
+)
+
+// ErrClosed is returned by the writes after Close.
+var ErrClosed = errors.New("write-behind kv is closed")
+
+// WriteBehind buffers the writes to a KV in memory, and flushes them in batches,
+// every FlushInterval or when MaxPending writes are buffered.
+// The writes to the same key are coalesced, and the reads see the pending writes.
+type WriteBehind struct {
+	KV     typing.KV
+	Config *Config
+
+	mu sync.RWMutex
+	// pending are the writes not flushed yet.
+	pending map[string]*op
+	// flushing are the writes being flushed, still visible to the reads.
+	flushing map[string]*op
+	closed   bool
+
+	// flushMu serializes the flushes, so that an old batch never overrides a new one.
+	flushMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Config is the configuration for WriteBehind.
+type Config struct {
+	// FlushInterval is the interval of the background flushes, default is 1 second.
+	FlushInterval time.Duration
+
+	// MaxPending is the number of pending keys which triggers a flush, default is 1000.
+	MaxPending int
+
+	// OnError is called with the errors of the background flushes, optional.
+	OnError func(err error)
+}
+
+// op is a pending write of a key.
+type op struct {
+	delete bool
+	// value is a copy of the value, so that the caller could reuse it after Set.
+	value any
+	raw   json.RawMessage
+	// expiresAt is the unix milliseconds of expiry, 0 for never.
+	expiresAt int64
+	// keepTTL keeps the expiry in KV, for Set without maxAge.
+	keepTTL bool
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+func (o *op) expired() bool {
+	return o.expiresAt > 0 && o.expiresAt <= now()
+}
+
+// New returns a new WriteBehind on kv, which flushes in the background until Close.
+func New(kv typing.KV, cfg ...*Config) *WriteBehind {
+	cfgX := &Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		cfgX = cfg[0]
+	}
+
+	if cfgX.FlushInterval <= 0 {
+		cfgX.FlushInterval = time.Second
+	}
+	if cfgX.MaxPending <= 0 {
+		cfgX.MaxPending = 1000
+	}
+
+	w := &WriteBehind{
+		KV:       kv,
+		Config:   cfgX,
+		pending:  make(map[string]*op),
+		flushing: make(map[string]*op),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go w.loop()
+	return w
+}
+
+func (w *WriteBehind) loop() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.Config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Flush(); err != nil && w.Config.OnError != nil {
+				w.Config.OnError(err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background flushes, and flushes the pending writes.
+func (w *WriteBehind) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+
+	return w.Flush()
+}
+
+// Flush writes the pending writes to KV, and returns once they are written.
+// The failed writes stay pending, unless written again since.
+func (w *WriteBehind) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	batch := w.pending
+	w.pending = make(map[string]*op)
+	w.flushing = batch
+	w.mu.Unlock()
+
+	var errs []error
+	failed := make(map[string]*op)
+	for key, o := range batch {
+		if err := w.apply(key, o); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			failed[key] = o
+		}
+	}
+
+	w.mu.Lock()
+	for key, o := range failed {
+		if _, ok := w.pending[key]; !ok {
+			w.pending[key] = o
+		}
+	}
+	w.flushing = make(map[string]*op)
+	w.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+func (w *WriteBehind) apply(key string, o *op) error {
+	if o.delete || o.expired() {
+		return w.KV.Delete(key)
+	}
+
+	if o.keepTTL {
+		return w.KV.Set(key, o.value)
+	}
+
+	if o.expiresAt > 0 {
+		return w.KV.Set(key, o.value, time.Duration(o.expiresAt-now())*time.Millisecond)
+	}
+
+	return typing.Replace(w.KV, key, o.value)
+}
+
+// lookup returns the pending write of the key, if any.
+func (w *WriteBehind) lookup(key string) (*op, bool) {
+	if o, ok := w.pending[key]; ok {
+		return o, true
+	}
+
+	o, ok := w.flushing[key]
+	return o, ok
+}
+
+// enqueue buffers the write, and flushes if MaxPending is reached.
+func (w *WriteBehind) enqueue(key string, o *op) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrClosed
+	}
+
+	w.pending[key] = o
+	full := len(w.pending) >= w.Config.MaxPending
+	w.mu.Unlock()
+
+	if full {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// Set buffers the value for the given key.
+// If maxAge is greater than 0, then the value will be expired after maxAge.
+func (w *WriteBehind) Set(key string, value any, maxAge ...time.Duration) error {
+	if value == nil {
+		return fmt.Errorf("value is nil")
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// copy the value, in a pointer of the same type
+	t := reflect.TypeOf(value)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	copied := reflect.New(t)
+	if err := json.Unmarshal(raw, copied.Interface()); err != nil {
+		return err
+	}
+
+	o := &op{value: copied.Interface(), raw: raw}
+	if len(maxAge) > 0 {
+		o.expiresAt = now() + maxAge[0].Milliseconds()
+	} else {
+		o.keepTTL = true
+
+		// a pending expiry is kept like the one in KV, while a pending
+		// delete drops the expiry of the record in KV
+		w.mu.RLock()
+		if prev, ok := w.lookup(key); ok {
+			if prev.delete || prev.expired() {
+				o.keepTTL = false
+			} else {
+				o.expiresAt = prev.expiresAt
+				o.keepTTL = prev.keepTTL
+			}
+		}
+		w.mu.RUnlock()
+	}
+
+	return w.enqueue(key, o)
+}
+
+// Get returns the value for the given key, pending or in KV.
+func (w *WriteBehind) Get(key string, value any) error {
+	w.mu.RLock()
+	o, ok := w.lookup(key)
+	w.mu.RUnlock()
+
+	if !ok {
+		return w.KV.Get(key, value)
+	}
+
+	if o.delete || o.expired() {
+		return fmt.Errorf("key %s not found", key)
+	}
+
+	return json.Unmarshal(o.raw, value)
+}
+
+// Delete buffers the deletion of the given key.
+func (w *WriteBehind) Delete(key string) error {
+	return w.enqueue(key, &op{delete: true})
+}
+
+// Has returns true if the given key exists, pending or in KV.
+func (w *WriteBehind) Has(key string) bool {
+	w.mu.RLock()
+	o, ok := w.lookup(key)
+	w.mu.RUnlock()
+
+	if !ok {
+		return w.KV.Has(key)
+	}
+
+	return !o.delete && !o.expired()
+}
+
+// Keys returns the keys of KV, with the pending writes.
+func (w *WriteBehind) Keys() []string {
+	keys := make(map[string]bool)
+	for _, key := range w.KV.Keys() {
+		keys[key] = true
+	}
+
+	w.mu.RLock()
+	for _, ops := range []map[string]*op{w.flushing, w.pending} {
+		for key, o := range ops {
+			keys[key] = !o.delete && !o.expired()
+		}
+	}
+	w.mu.RUnlock()
+
+	result := make([]string, 0, len(keys))
+	for key, ok := range keys {
+		if ok {
+			result = append(result, key)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// Size returns the number of elements, pending or in KV.
+func (w *WriteBehind) Size() int {
+	return len(w.Keys())
+}
+
+// Clear drops the pending writes, and clears KV right away.
+func (w *WriteBehind) Clear() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	w.pending = make(map[string]*op)
+	w.mu.Unlock()
+
+	return w.KV.Clear()
+}
+
+// ForEach calls the given function for each key-value pair, pending or in KV.
+func (w *WriteBehind) ForEach(f func(string, interface{})) {
+	for _, key := range w.Keys() {
+		var value any
+		if err := w.Get(key, &value); err != nil {
+			f(key, nil)
+		} else {
+			f(key, value)
+		}
+	}
+}
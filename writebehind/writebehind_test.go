@@ -0,0 +1,140 @@
+package writebehind
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zoox/kv/memory"
+	"github.com/go-zoox/kv/test"
+	"github.com/go-zoox/kv/typing"
+)
+
+// countingKV counts the writes to a KV.
+type countingKV struct {
+	typing.KV
+	sync.Mutex
+	sets int
+}
+
+func (c *countingKV) Set(key string, value any, maxAge ...time.Duration) error {
+	c.Lock()
+	c.sets++
+	c.Unlock()
+
+	return c.KV.Set(key, value, maxAge...)
+}
+
+func (c *countingKV) count() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.sets
+}
+
+func TestKV(t *testing.T) {
+	w := New(memory.New(), &Config{FlushInterval: 10 * time.Millisecond})
+	defer w.Close()
+
+	test.RunTestCases(t, w)
+}
+
+func TestPending(t *testing.T) {
+	kv := &countingKV{KV: memory.New()}
+	w := New(kv, &Config{FlushInterval: time.Hour})
+	defer w.Close()
+
+	value := "v1"
+	w.Set("key", &value)
+	// the caller could reuse the value
+	value = "v2"
+	w.Set("key", &value)
+	w.Set("deleted", &value)
+	w.Delete("deleted")
+
+	var got string
+	if err := w.Get("key", &got); err != nil || got != "v2" {
+		t.Errorf("Expected pending v2, got %q, %v", got, err)
+	}
+	if w.Has("deleted") || !w.Has("key") || w.Size() != 1 {
+		t.Errorf("Expected pending writes visible, got keys %v", w.Keys())
+	}
+	if kv.count() != 0 || kv.Has("key") {
+		t.Error("Expected no write before flush")
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if kv.count() != 1 {
+		t.Errorf("Expected writes coalesced into 1 set, got %d", kv.count())
+	}
+	if err := kv.Get("key", &got); err != nil || got != "v2" {
+		t.Errorf("Expected v2 flushed, got %q, %v", got, err)
+	}
+}
+
+func TestMaxPending(t *testing.T) {
+	kv := &countingKV{KV: memory.New()}
+	w := New(kv, &Config{FlushInterval: time.Hour, MaxPending: 3})
+	defer w.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		w.Set(key, &key)
+	}
+	if kv.count() != 3 {
+		t.Errorf("Expected flush at MaxPending, got %d sets", kv.count())
+	}
+}
+
+func TestInterval(t *testing.T) {
+	kv := &countingKV{KV: memory.New()}
+	w := New(kv, &Config{FlushInterval: 10 * time.Millisecond})
+	defer w.Close()
+
+	value := "value"
+	w.Set("key", &value)
+	time.Sleep(50 * time.Millisecond)
+	if !kv.Has("key") {
+		t.Error("Expected flush after FlushInterval")
+	}
+}
+
+func TestClose(t *testing.T) {
+	kv := memory.New()
+	w := New(kv, &Config{FlushInterval: time.Hour})
+
+	value := "value"
+	w.Set("key", &value, time.Minute)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl, err := kv.TTL("key"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected flushed key with ttl, got %s, %v", ttl, err)
+	}
+	if err := w.Set("key", &value); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestSetAfterDelete(t *testing.T) {
+	kv := memory.New()
+	w := New(kv, &Config{FlushInterval: time.Hour})
+	defer w.Close()
+
+	value := "value"
+	if err := kv.Set("key", &value, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	w.Delete("key")
+	w.Set("key", &value)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl, err := kv.TTL("key"); err != nil || ttl != -1 {
+		t.Errorf("Expected key without expiry, got %s, %v", ttl, err)
+	}
+}